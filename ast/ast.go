@@ -1,10 +1,20 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
 
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Node is a node of the syntax tree. Pos is the position of its first
+// character and End the position just after its last one. The semicolon
+// ending a statement and the parentheses around an expression are not part
+// of them, so the span of (a + b) * c starts at a.
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
+	End() token.Position
 }
 
 type Program struct {
@@ -18,10 +28,57 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
+	return statementsString(p.Statements)
+}
+
+// statementsString prints statements separated by spaces, ending the
+// expression statements that are followed by another statement with a
+// semicolon so that they do not run into it.
+func statementsString(statements []Statement) string {
 	var out bytes.Buffer
-	for _, s := range p.Statements {
+	for i, s := range statements {
+		if i > 0 {
+			out.WriteString(" ")
+		}
 		out.WriteString(s.String())
+		if _, ok := s.(*ExpressionStatement); ok && i < len(statements)-1 {
+			out.WriteString(";")
+		}
 	}
 	return out.String()
 }
+
+// tokenEnd returns the position just after tok. Only string literals may
+// span several lines.
+func tokenEnd(tok token.Token) token.Position {
+	text := tok.Literal
+	if tok.Type == token.String {
+		text = "\"" + text + "\""
+	}
+
+	end := tok.Pos
+	for _, ch := range text {
+		if ch == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	return end
+}
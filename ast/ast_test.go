@@ -0,0 +1,342 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+	return program
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = -1 + 2;", "Program LetStatement Identifier InfixExpression PrefixExpression IntegerLiteral IntegerLiteral"},
+		{"return 1;", "Program ReturnStatement IntegerLiteral"},
+		{`if (true) { "a" } else { x }`,
+			"Program ExpressionStatement IfExpression BooleanLiteral BlockStatement ExpressionStatement StringLiteral BlockStatement ExpressionStatement Identifier"},
+		{"fn(a, b = 1, ...c) { a }",
+			"Program ExpressionStatement FunctionLiteral IdentifierPattern Identifier DefaultPattern IdentifierPattern Identifier IntegerLiteral RestPattern Identifier BlockStatement ExpressionStatement Identifier"},
+		{"f(1, x: [2], y: {3: 4})",
+			"Program ExpressionStatement CallExpression Identifier IntegerLiteral NamedArgument Identifier ArrayLiteral IntegerLiteral NamedArgument Identifier HashLiteral IntegerLiteral IntegerLiteral"},
+		{`match (v) { [1, _] if ok => 1, {"k": k} => k }`,
+			"Program ExpressionStatement MatchExpression Identifier MatchArm ArrayPattern LiteralPattern IntegerLiteral WildcardPattern Identifier IntegerLiteral MatchArm HashPattern StringLiteral IdentifierPattern Identifier Identifier"},
+		{"let [a, {b}] = v;", "Program LetStatement ArrayPattern IdentifierPattern Identifier HashPattern StringLiteral IdentifierPattern Identifier Identifier"},
+		{"try { throw e.x } catch (err) { 1 } finally { 2 }",
+			"Program ExpressionStatement TryExpression BlockStatement ThrowStatement MemberExpression Identifier Identifier IdentifierPattern Identifier BlockStatement ExpressionStatement IntegerLiteral BlockStatement ExpressionStatement IntegerLiteral"},
+		{`import "m.mk" as m; export let x = 1;`,
+			"Program ImportStatement StringLiteral Identifier ExportStatement LetStatement Identifier IntegerLiteral"},
+	}
+
+	for _, tt := range tests {
+		names := []string{}
+		ast.Inspect(parse(t, tt.input), func(node ast.Node) bool {
+			if node != nil {
+				names = append(names, strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast."))
+			}
+			return true
+		})
+		assert.Equal(t, tt.expected, strings.Join(names, " "), tt.input)
+	}
+}
+
+func TestInspectPrunes(t *testing.T) {
+	program := parse(t, "let f = fn(x) { x + y }; f(z)")
+
+	identifiers := []string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		_, isFunction := node.(*ast.FunctionLiteral)
+		return !isFunction
+	})
+
+	assert.Equal(t, []string{"f", "f", "z"}, identifiers)
+}
+
+type depthVisitor struct {
+	depth    *int
+	maxDepth *int
+}
+
+func (v depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*v.depth--
+		return nil
+	}
+	*v.depth++
+	if *v.depth > *v.maxDepth {
+		*v.maxDepth = *v.depth
+	}
+	return v
+}
+
+func TestWalkCallsVisitNilAfterChildren(t *testing.T) {
+	depth, maxDepth := 0, 0
+	ast.Walk(depthVisitor{&depth, &maxDepth}, parse(t, "if (a) { b(c) }"))
+
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 7, maxDepth)
+}
+
+func TestModify(t *testing.T) {
+	integer := func(value int64) ast.Expression {
+		literal := fmt.Sprint(value)
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.Int, Literal: literal}, Value: value}
+	}
+
+	turnOneIntoTwo := func(node ast.Node) ast.Node {
+		literal, ok := node.(*ast.IntegerLiteral)
+		if !ok || literal.Value != 1 {
+			return node
+		}
+		return integer(2)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1", "2"},
+		{"1 + 2; -1", "(2 + 2); (-2)"},
+		{"if (1) { 1 }", "if (2) { 2 }"},
+		{"if (1) { 1 } else { 1 }", "if (2) { 2 } else { 2 }"},
+		{"fn([b, c], a = 1) { return 1; }", "fn([b, c], a = 2) { return 2; }"},
+		{"f(1, [1], x: 1)", "f(2, [2], x: 2)"},
+		{"let [a, b] = [1, 1];", "let [a, b] = [2, 2];"},
+		{`let h = {1: 1}; match (1) { 1 if 1 => 1, {"k": 1} => 1 }`,
+			`let h = {2: 2}; match(2) { 2 if 2 => 2, {"k": 2} => 2 }`},
+		{"try { throw 1 } catch (e) { 1 } finally { 1 }", "try { throw 2; } catch(e) { 2 } finally { 2 }"},
+	}
+
+	for _, tt := range tests {
+		modified := ast.Modify(parse(t, tt.input), turnOneIntoTwo)
+		assert.Equal(t, tt.expected, modified.String(), tt.input)
+	}
+
+	exp := ast.Modify(integer(1), turnOneIntoTwo)
+	assert.Equal(t, "2", exp.String())
+
+	assert.PanicsWithValue(t, "ast.Modify: cannot replace *ast.BlockStatement with *ast.IntegerLiteral", func() {
+		ast.Modify(parse(t, "if (x) { y }"), func(node ast.Node) ast.Node {
+			if _, ok := node.(*ast.BlockStatement); ok {
+				return integer(1)
+			}
+			return node
+		})
+	})
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"let x = 1;", "let  x =\n  1", true},
+		{"a + b * c", "(a + (b * c))", true},
+		{"f(a, b: [1])", "f(a, c: [1])", false},
+		{"1", "01", false},
+		{`"x"`, "x", false},
+		{"if (x) { 1 }", "if (x) { 1 } else {}", false},
+		{"fn(a, ...b) { a }", "fn(a, b) { a }", false},
+		{"match (v) { {name} => 1 }", `match (v) { {"name": name} => 1 }`, true},
+		{"match (v) { {name} => 1 }", `match (v) { {"name": other} => 1 }`, false},
+		{"try { 1 } catch (e) {}", "try { 1 } finally {}", false},
+		{"a; b", "a", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ast.Equal(parse(t, tt.a), parse(t, tt.b)), "%s == %s", tt.a, tt.b)
+	}
+
+	assert.True(t, ast.Equal(nil, nil))
+	assert.False(t, ast.Equal(parse(t, "x"), nil))
+}
+
+func TestClone(t *testing.T) {
+	inputs := []string{
+		"let a = -1 + 2 * x; !true",
+		`if (a) { "a" } else { let c = [1, a.b, f(c)(d)]; c }`,
+		"let f = fn([a, _], {\"k\": v}, b = 1, ...c) { return a; }; f(1, x: {2: 3})",
+		"match (v) { [1, _] if ok => 1, {name} => fn() {}, _ => 3 }",
+		`import "m.mk" as m; export let x = 1; try { throw e } catch (e) { 1 } finally { 2 }`,
+	}
+
+	rename := func(node ast.Node) ast.Node {
+		if identifier, ok := node.(*ast.Identifier); ok {
+			identifier.Value += "2"
+		}
+		return node
+	}
+
+	for _, input := range inputs {
+		program := parse(t, input)
+		printed := program.String()
+
+		clone := ast.Clone(program)
+		assert.Equal(t, program, clone, input)
+		assert.True(t, ast.Equal(program, clone), input)
+
+		ast.Modify(clone, rename)
+		assert.Equal(t, printed, program.String(), input)
+		assert.NotEqual(t, printed, clone.String(), input)
+	}
+}
+
+// text returns the part of input between the positions of node.
+func text(input string, node ast.Node) string {
+	offset := func(pos token.Position) int {
+		lines := strings.SplitAfter(input, "\n")
+		offset := 0
+		for _, line := range lines[:pos.Line-1] {
+			offset += len([]rune(line))
+		}
+		return offset + pos.Column - 1
+	}
+	return string([]rune(input)[offset(node.Pos()):offset(node.End())])
+}
+
+func TestSpans(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = -1 + 2 * x;", []string{"let a = -1 + 2 * x", "a", "-1 + 2 * x", "-1", "1", "2 * x", "2", "x"}},
+		{"f(a, b: [1])(c).d", []string{"f(a, b: [1])(c).d", "f(a, b: [1])(c).d", "f(a, b: [1])(c)",
+			"f(a, b: [1])", "f", "a", "b: [1]", "b", "[1]", "1", "c", "d"}},
+		{"if (x) {\n  \"é\"\n} else {}", []string{"if (x) {\n  \"é\"\n} else {}",
+			"if (x) {\n  \"é\"\n} else {}", "x", "{\n  \"é\"\n}", "\"é\"", "\"é\"", "{}"}},
+		{"return \"a\nb\";", []string{"return \"a\nb\"", "\"a\nb\""}},
+		{"match (v) { [_, ...r] => {\"k\": r}, {name} if name => fn(n = 1) { n } }", []string{
+			"match (v) { [_, ...r] => {\"k\": r}, {name} if name => fn(n = 1) { n } }",
+			"match (v) { [_, ...r] => {\"k\": r}, {name} if name => fn(n = 1) { n } }", "v",
+			"[_, ...r] => {\"k\": r}", "[_, ...r]", "_", "...r", "r", "{\"k\": r}", "\"k\"", "r",
+			"{name} if name => fn(n = 1) { n }", "{name}", "name", "name", "name", "name",
+			"fn(n = 1) { n }", "n = 1", "n", "n", "1", "{ n }", "n", "n"}},
+		{"try { throw e } catch (e) {}", []string{"try { throw e } catch (e) {}", "try { throw e } catch (e) {}",
+			"{ throw e }", "throw e", "e", "e", "e", "{}"}},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+
+		texts := []string{}
+		for _, stmt := range program.Statements {
+			ast.Inspect(stmt, func(node ast.Node) bool {
+				if node != nil {
+					texts = append(texts, text(tt.input, node))
+				}
+				return true
+			})
+		}
+		assert.Equal(t, tt.expected, texts, tt.input)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	inputs := []string{
+		"let a = -1 + 2 * x; !true",
+		`if ((a < b) == false) { "a" } else { let c = [1, a.b, f(c)(d)]; c }`,
+		"let f = fn([a, _], {\"k\": v}, b = 1, ...c) { return a; }; f(1, x: {2: 3}, y: {})",
+		`match (v) { [1, _] if ok => 1, -2 => fn() {}, _ => 3 }`,
+		"try { throw e.x } catch ({message}) { 1 } finally { 2 }",
+		`import "m.mk" as m; export let x = 1; try { 1 } finally {}`,
+	}
+
+	for _, input := range inputs {
+		program := parse(t, input)
+
+		data, err := json.Marshal(program)
+		assert.NoError(t, err, input)
+
+		decoded := &ast.Program{}
+		assert.NoError(t, json.Unmarshal(data, decoded), input)
+		assert.Equal(t, program, decoded, input)
+	}
+
+	data, err := json.Marshal(parse(t, "-x"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"kind": "Program", "statements": [{
+		"kind": "ExpressionStatement", "pos": {"line": 1, "column": 1}, "end": {"line": 1, "column": 3},
+		"expression": {
+			"kind": "PrefixExpression", "pos": {"line": 1, "column": 1}, "end": {"line": 1, "column": 3},
+			"operator": "-",
+			"right": {"kind": "Identifier", "pos": {"line": 1, "column": 2}, "end": {"line": 1, "column": 3}, "value": "x"}
+		}
+	}]}`, string(data))
+}
+
+func TestUnmarshalNodeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind": "Loop"}`, `ast: unknown node kind "Loop"`},
+		{`{"kind": "PrefixExpression", "operator": "-"}`, "ast: missing right in PrefixExpression"},
+		{`{"kind": "ThrowStatement", "value": {"kind": "WildcardPattern"}}`,
+			"ast: expected an expression in value of ThrowStatement"},
+		{`{"kind": "Program", "statements": [{"kind": "Identifier", "value": "x"}]}`,
+			"ast: expected a statement in statements of Program"},
+		{`[]`, "ast: json: cannot unmarshal array into Go value of type ast.jsonNode"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.UnmarshalNode([]byte(tt.input))
+		assert.EqualError(t, err, tt.expected, tt.input)
+	}
+}
+
+func TestDot(t *testing.T) {
+	expected := `digraph ast {
+	ordering=out;
+	node [shape=box];
+	n1 [label="Program"];
+	n2 [label="ExpressionStatement"];
+	n3 [label="InfixExpression\n+"];
+	n4 [label="IntegerLiteral\n1"];
+	n3 -> n4 [label="left"];
+	n5 [label="CallExpression"];
+	n6 [label="Identifier\nf"];
+	n5 -> n6 [label="function"];
+	n7 [label="StringLiteral\n\"a\""];
+	n5 -> n7 [label="arguments"];
+	n8 [label="HashLiteral"];
+	n9 [label="Pair"];
+	n10 [label="Identifier\nk"];
+	n9 -> n10 [label="key"];
+	n11 [label="BooleanLiteral\ntrue"];
+	n9 -> n11 [label="value"];
+	n8 -> n9 [label="pairs"];
+	n5 -> n8 [label="arguments"];
+	n3 -> n5 [label="right"];
+	n2 -> n3 [label="expression"];
+	n1 -> n2 [label="statements"];
+}
+`
+	assert.Equal(t, expected, ast.Dot(parse(t, `1 + f("a", {k: true})`)))
+
+	expected = `digraph ast {
+	ordering=out;
+	node [shape=box];
+	n1 [label="StringLiteral\n\"say \"héllo\" \\ 世界\""];
+}
+`
+	assert.Equal(t, expected, ast.Dot(&ast.StringLiteral{Value: `say "héllo" \ 世界`}))
+}
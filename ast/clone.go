@@ -0,0 +1,216 @@
+package ast
+
+import "fmt"
+
+// Clone returns a deep copy of the tree rooted at node, which shares no
+// nodes or slices with it, so that either can be modified without
+// affecting the other.
+func Clone(node Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		return &Program{Statements: cloneStatements(n.Statements)}
+
+	// Statements
+	case *LetStatement:
+		return &LetStatement{
+			Token:   n.Token,
+			Name:    cloneIdentifier(n.Name),
+			Pattern: clonePattern(n.Pattern),
+			Value:   cloneExpression(n.Value),
+		}
+	case *ReturnStatement:
+		return &ReturnStatement{Token: n.Token, ReturnValue: cloneExpression(n.ReturnValue)}
+	case *ExpressionStatement:
+		return &ExpressionStatement{Token: n.Token, Expression: cloneExpression(n.Expression)}
+	case *BlockStatement:
+		return cloneBlock(n)
+	case *ImportStatement:
+		return &ImportStatement{Token: n.Token, Path: Clone(n.Path).(*StringLiteral), Alias: cloneIdentifier(n.Alias)}
+	case *ExportStatement:
+		return &ExportStatement{Token: n.Token, Statement: Clone(n.Statement).(*LetStatement)}
+	case *ThrowStatement:
+		return &ThrowStatement{Token: n.Token, Value: cloneExpression(n.Value)}
+
+	// Expressions
+	case *Identifier:
+		return cloneIdentifier(n)
+	case *IntegerLiteral:
+		literal := *n
+		return &literal
+	case *BooleanLiteral:
+		boolean := *n
+		return &boolean
+	case *StringLiteral:
+		literal := *n
+		return &literal
+	case *PrefixExpression:
+		return &PrefixExpression{Token: n.Token, Operator: n.Operator, Right: cloneExpression(n.Right)}
+	case *InfixExpression:
+		return &InfixExpression{
+			Token:    n.Token,
+			Operator: n.Operator,
+			Left:     cloneExpression(n.Left),
+			Right:    cloneExpression(n.Right),
+		}
+	case *IfExpression:
+		return &IfExpression{
+			Token:       n.Token,
+			Condition:   cloneExpression(n.Condition),
+			Consequence: cloneBlock(n.Consequence),
+			Alternative: cloneBlock(n.Alternative),
+		}
+	case *FunctionLiteral:
+		return &FunctionLiteral{Token: n.Token, Parameters: clonePatterns(n.Parameters), Body: cloneBlock(n.Body)}
+	case *CallExpression:
+		exp := &CallExpression{
+			Token:      n.Token,
+			Function:   cloneExpression(n.Function),
+			Arguments:  cloneExpressions(n.Arguments),
+			RightParen: n.RightParen,
+		}
+		if n.NamedArguments != nil {
+			exp.NamedArguments = make([]*NamedArgument, len(n.NamedArguments))
+			for i, arg := range n.NamedArguments {
+				exp.NamedArguments[i] = Clone(arg).(*NamedArgument)
+			}
+		}
+		return exp
+	case *NamedArgument:
+		return &NamedArgument{Name: cloneIdentifier(n.Name), Value: cloneExpression(n.Value)}
+	case *ArrayLiteral:
+		return &ArrayLiteral{Token: n.Token, Elements: cloneExpressions(n.Elements), RightBracket: n.RightBracket}
+	case *HashLiteral:
+		hash := &HashLiteral{Token: n.Token, RightBrace: n.RightBrace}
+		if n.Pairs != nil {
+			hash.Pairs = make([]HashPair, len(n.Pairs))
+			for i, pair := range n.Pairs {
+				hash.Pairs[i] = HashPair{Key: cloneExpression(pair.Key), Value: cloneExpression(pair.Value)}
+			}
+		}
+		return hash
+	case *MatchExpression:
+		exp := &MatchExpression{Token: n.Token, Subject: cloneExpression(n.Subject), RightBrace: n.RightBrace}
+		if n.Arms != nil {
+			exp.Arms = make([]*MatchArm, len(n.Arms))
+			for i, arm := range n.Arms {
+				exp.Arms[i] = Clone(arm).(*MatchArm)
+			}
+		}
+		return exp
+	case *MatchArm:
+		return &MatchArm{
+			Pattern: clonePattern(n.Pattern),
+			Guard:   cloneExpression(n.Guard),
+			Body:    cloneExpression(n.Body),
+		}
+	case *MemberExpression:
+		return &MemberExpression{Token: n.Token, Object: cloneExpression(n.Object), Property: cloneIdentifier(n.Property)}
+	case *TryExpression:
+		return &TryExpression{
+			Token:     n.Token,
+			Block:     cloneBlock(n.Block),
+			Parameter: clonePattern(n.Parameter),
+			Catch:     cloneBlock(n.Catch),
+			Finally:   cloneBlock(n.Finally),
+		}
+
+	// Patterns
+	case *LiteralPattern:
+		return &LiteralPattern{Token: n.Token, Value: cloneExpression(n.Value)}
+	case *IdentifierPattern:
+		return &IdentifierPattern{Token: n.Token, Name: cloneIdentifier(n.Name)}
+	case *WildcardPattern:
+		return &WildcardPattern{Token: n.Token}
+	case *RestPattern:
+		return &RestPattern{Token: n.Token, Name: cloneIdentifier(n.Name)}
+	case *DefaultPattern:
+		return &DefaultPattern{Token: n.Token, Target: clonePattern(n.Target), Default: cloneExpression(n.Default)}
+	case *ArrayPattern:
+		return &ArrayPattern{Token: n.Token, Elements: clonePatterns(n.Elements), RightBracket: n.RightBracket}
+	case *HashPattern:
+		pattern := &HashPattern{Token: n.Token, RightBrace: n.RightBrace}
+		if n.Pairs != nil {
+			pattern.Pairs = make([]HashPatternPair, len(n.Pairs))
+			for i, pair := range n.Pairs {
+				pattern.Pairs[i] = HashPatternPair{Key: cloneExpression(pair.Key), Value: clonePattern(pair.Value)}
+			}
+		}
+		return pattern
+
+	default:
+		panic(fmt.Sprintf("ast.Clone: unexpected node type %T", n))
+	}
+}
+
+// The helpers below keep absent children nil, and nil slices nil, so that
+// a copy compares equal to its original with reflect.DeepEqual.
+
+func cloneBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	return &BlockStatement{
+		Token:      block.Token,
+		Statements: cloneStatements(block.Statements),
+		RightBrace: block.RightBrace,
+	}
+}
+
+func cloneIdentifier(ident *Identifier) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	clone := *ident
+	return &clone
+}
+
+func cloneExpression(exp Expression) Expression {
+	if exp == nil {
+		return nil
+	}
+	return Clone(exp).(Expression)
+}
+
+func clonePattern(pattern Pattern) Pattern {
+	if pattern == nil {
+		return nil
+	}
+	return Clone(pattern).(Pattern)
+}
+
+func cloneStatements(stmts []Statement) []Statement {
+	if stmts == nil {
+		return nil
+	}
+	clones := make([]Statement, len(stmts))
+	for i, stmt := range stmts {
+		clones[i] = Clone(stmt).(Statement)
+	}
+	return clones
+}
+
+func cloneExpressions(exps []Expression) []Expression {
+	if exps == nil {
+		return nil
+	}
+	clones := make([]Expression, len(exps))
+	for i, exp := range exps {
+		clones[i] = cloneExpression(exp)
+	}
+	return clones
+}
+
+func clonePatterns(patterns []Pattern) []Pattern {
+	if patterns == nil {
+		return nil
+	}
+	clones := make([]Pattern, len(patterns))
+	for i, pattern := range patterns {
+		clones[i] = clonePattern(pattern)
+	}
+	return clones
+}
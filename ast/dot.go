@@ -0,0 +1,95 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dot returns a Graphviz graph of the tree rooted at node. Each node is
+// labelled with its type, followed by its operator or value if it has one,
+// and the edges to its children are labelled with the fields holding them.
+// The pairs of hashes and hash patterns get a node of their own.
+func Dot(node Node) string {
+	g := &dotGraph{}
+
+	g.out.WriteString("digraph ast {\n")
+	g.out.WriteString("\tordering=out;\n")
+	g.out.WriteString("\tnode [shape=box];\n")
+	g.node(node)
+	g.out.WriteString("}\n")
+
+	return g.out.String()
+}
+
+type dotGraph struct {
+	out   strings.Builder
+	nodes int
+}
+
+// node adds node and its children to the graph, and returns its id. The
+// children are found through the members of the JSON encoding of node.
+func (g *dotGraph) node(node Node) string {
+	id := g.newID()
+	members := members(node)
+
+	lines := []string{}
+	for _, member := range members {
+		switch value := member.value.(type) {
+		case string:
+			if _, ok := node.(*StringLiteral); ok && member.name == "value" {
+				value = `"` + value + `"`
+			}
+			lines = append(lines, value)
+		case int64, bool:
+			lines = append(lines, fmt.Sprint(value))
+		}
+	}
+	g.vertex(id, lines...)
+
+	for _, member := range members {
+		switch value := member.value.(type) {
+		case Node:
+			g.edge(id, g.node(value), member.name)
+		case []Node:
+			for _, child := range value {
+				g.edge(id, g.node(child), member.name)
+			}
+		case []jsonObject:
+			for _, pair := range value {
+				g.edge(id, g.pair(pair), member.name)
+			}
+		}
+	}
+
+	return id
+}
+
+func (g *dotGraph) pair(pair jsonObject) string {
+	id := g.newID()
+	g.vertex(id, "Pair")
+	for _, member := range pair {
+		g.edge(id, g.node(member.value.(Node)), member.name)
+	}
+	return id
+}
+
+func (g *dotGraph) newID() string {
+	g.nodes++
+	return fmt.Sprintf("n%d", g.nodes)
+}
+
+// vertex adds the node id labelled with lines, one below the other.
+func (g *dotGraph) vertex(id string, lines ...string) {
+	for i, line := range lines {
+		lines[i] = dotEscaper.Replace(line)
+	}
+	fmt.Fprintf(&g.out, "\t%s [label=\"%s\"];\n", id, strings.Join(lines, `\n`))
+}
+
+func (g *dotGraph) edge(from, to, label string) {
+	fmt.Fprintf(&g.out, "\t%s -> %s [label=\"%s\"];\n", from, to, dotEscaper.Replace(label))
+}
+
+// dotEscaper escapes the only characters that are special in a quoted DOT
+// string, leaving the rest of the text as it is.
+var dotEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
@@ -0,0 +1,220 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Equal reports whether a and b are trees of the same shape, whose nodes
+// have the same tokens and values. Positions are ignored, and so are the
+// tokens that only mark where a node starts or ends: the braces of blocks,
+// the closing brackets and the first token of an expression statement.
+func Equal(a, b Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	switch a := a.(type) {
+	case *Program:
+		b, ok := b.(*Program)
+		return ok && equalStatements(a.Statements, b.Statements)
+
+	// Statements
+	case *LetStatement:
+		b, ok := b.(*LetStatement)
+		return ok && sameToken(a.Token, b.Token) &&
+			equalIdentifiers(a.Name, b.Name) && Equal(a.Pattern, b.Pattern) && Equal(a.Value, b.Value)
+	case *ReturnStatement:
+		b, ok := b.(*ReturnStatement)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.ReturnValue, b.ReturnValue)
+	case *ExpressionStatement:
+		b, ok := b.(*ExpressionStatement)
+		return ok && Equal(a.Expression, b.Expression)
+	case *BlockStatement:
+		b, ok := b.(*BlockStatement)
+		return ok && equalBlocks(a, b)
+	case *ImportStatement:
+		b, ok := b.(*ImportStatement)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Path, b.Path) && Equal(a.Alias, b.Alias)
+	case *ExportStatement:
+		b, ok := b.(*ExportStatement)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Statement, b.Statement)
+	case *ThrowStatement:
+		b, ok := b.(*ThrowStatement)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Value, b.Value)
+
+	// Expressions
+	case *Identifier:
+		b, ok := b.(*Identifier)
+		return ok && equalIdentifiers(a, b)
+	case *IntegerLiteral:
+		b, ok := b.(*IntegerLiteral)
+		return ok && sameToken(a.Token, b.Token) && a.Value == b.Value
+	case *BooleanLiteral:
+		b, ok := b.(*BooleanLiteral)
+		return ok && sameToken(a.Token, b.Token) && a.Value == b.Value
+	case *StringLiteral:
+		// The token of a string is its name in the key of a shorthand hash
+		// pattern {name}, so only the values are compared.
+		b, ok := b.(*StringLiteral)
+		return ok && a.Value == b.Value
+	case *PrefixExpression:
+		b, ok := b.(*PrefixExpression)
+		return ok && sameToken(a.Token, b.Token) && a.Operator == b.Operator && Equal(a.Right, b.Right)
+	case *InfixExpression:
+		b, ok := b.(*InfixExpression)
+		return ok && sameToken(a.Token, b.Token) && a.Operator == b.Operator &&
+			Equal(a.Left, b.Left) && Equal(a.Right, b.Right)
+	case *IfExpression:
+		b, ok := b.(*IfExpression)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Condition, b.Condition) &&
+			equalBlocks(a.Consequence, b.Consequence) && equalBlocks(a.Alternative, b.Alternative)
+	case *FunctionLiteral:
+		b, ok := b.(*FunctionLiteral)
+		return ok && sameToken(a.Token, b.Token) &&
+			equalPatterns(a.Parameters, b.Parameters) && equalBlocks(a.Body, b.Body)
+	case *CallExpression:
+		b, ok := b.(*CallExpression)
+		if !ok || !sameToken(a.Token, b.Token) || !Equal(a.Function, b.Function) ||
+			!equalExpressions(a.Arguments, b.Arguments) || len(a.NamedArguments) != len(b.NamedArguments) {
+			return false
+		}
+		for i := range a.NamedArguments {
+			if !Equal(a.NamedArguments[i], b.NamedArguments[i]) {
+				return false
+			}
+		}
+		return true
+	case *NamedArgument:
+		b, ok := b.(*NamedArgument)
+		return ok && equalIdentifiers(a.Name, b.Name) && Equal(a.Value, b.Value)
+	case *ArrayLiteral:
+		b, ok := b.(*ArrayLiteral)
+		return ok && sameToken(a.Token, b.Token) && equalExpressions(a.Elements, b.Elements)
+	case *HashLiteral:
+		b, ok := b.(*HashLiteral)
+		if !ok || !sameToken(a.Token, b.Token) || len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		for i := range a.Pairs {
+			if !Equal(a.Pairs[i].Key, b.Pairs[i].Key) || !Equal(a.Pairs[i].Value, b.Pairs[i].Value) {
+				return false
+			}
+		}
+		return true
+	case *MatchExpression:
+		b, ok := b.(*MatchExpression)
+		if !ok || !sameToken(a.Token, b.Token) || !Equal(a.Subject, b.Subject) || len(a.Arms) != len(b.Arms) {
+			return false
+		}
+		for i := range a.Arms {
+			if !Equal(a.Arms[i], b.Arms[i]) {
+				return false
+			}
+		}
+		return true
+	case *MatchArm:
+		b, ok := b.(*MatchArm)
+		return ok && Equal(a.Pattern, b.Pattern) && Equal(a.Guard, b.Guard) && Equal(a.Body, b.Body)
+	case *MemberExpression:
+		b, ok := b.(*MemberExpression)
+		return ok && sameToken(a.Token, b.Token) &&
+			Equal(a.Object, b.Object) && equalIdentifiers(a.Property, b.Property)
+	case *TryExpression:
+		b, ok := b.(*TryExpression)
+		return ok && sameToken(a.Token, b.Token) && equalBlocks(a.Block, b.Block) &&
+			Equal(a.Parameter, b.Parameter) && equalBlocks(a.Catch, b.Catch) && equalBlocks(a.Finally, b.Finally)
+
+	// Patterns
+	case *LiteralPattern:
+		b, ok := b.(*LiteralPattern)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Value, b.Value)
+	case *IdentifierPattern:
+		b, ok := b.(*IdentifierPattern)
+		return ok && sameToken(a.Token, b.Token) && equalIdentifiers(a.Name, b.Name)
+	case *WildcardPattern:
+		b, ok := b.(*WildcardPattern)
+		return ok && sameToken(a.Token, b.Token)
+	case *RestPattern:
+		b, ok := b.(*RestPattern)
+		return ok && sameToken(a.Token, b.Token) && equalIdentifiers(a.Name, b.Name)
+	case *DefaultPattern:
+		b, ok := b.(*DefaultPattern)
+		return ok && sameToken(a.Token, b.Token) && Equal(a.Target, b.Target) && Equal(a.Default, b.Default)
+	case *ArrayPattern:
+		b, ok := b.(*ArrayPattern)
+		return ok && sameToken(a.Token, b.Token) && equalPatterns(a.Elements, b.Elements)
+	case *HashPattern:
+		b, ok := b.(*HashPattern)
+		if !ok || !sameToken(a.Token, b.Token) || len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		for i := range a.Pairs {
+			if !Equal(a.Pairs[i].Key, b.Pairs[i].Key) || !Equal(a.Pairs[i].Value, b.Pairs[i].Value) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		panic(fmt.Sprintf("ast.Equal: unexpected node type %T", a))
+	}
+}
+
+func sameToken(a, b token.Token) bool {
+	return a.Type == b.Type && a.Literal == b.Literal
+}
+
+// The optional children held in pointers of a concrete type are compared by
+// the helpers below, since a nil pointer stored in a Node is not nil.
+
+func equalBlocks(a, b *BlockStatement) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return equalStatements(a.Statements, b.Statements)
+}
+
+func equalIdentifiers(a, b *Identifier) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameToken(a.Token, b.Token) && a.Value == b.Value
+}
+
+func equalStatements(a, b []Statement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalExpressions(a, b []Expression) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPatterns(a, b []Pattern) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
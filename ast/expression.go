@@ -15,6 +15,16 @@ type Expression interface {
 type Identifier struct {
 	Token token.Token
 	Value string
+	// Binding is set by the resolver when the identifier names a local
+	// variable. Globals are left nil and looked up by name.
+	Binding *Binding
+}
+
+// Binding locates a local variable: it is in slot Slot of the environment
+// Depth levels out from the one the identifier is evaluated in.
+type Binding struct {
+	Depth int
+	Slot  int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -23,6 +33,14 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
+func (i *Identifier) End() token.Position {
+	return tokenEnd(i.Token)
+}
+
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -39,6 +57,14 @@ func (exp *PrefixExpression) TokenLiteral() string {
 	return exp.Token.Literal
 }
 
+func (exp *PrefixExpression) Pos() token.Position {
+	return exp.Token.Pos
+}
+
+func (exp *PrefixExpression) End() token.Position {
+	return exp.Right.End()
+}
+
 func (exp *PrefixExpression) String() string {
 	var out strings.Builder
 	out.WriteString("(")
@@ -61,6 +87,14 @@ func (exp *InfixExpression) TokenLiteral() string {
 	return exp.Token.Literal
 }
 
+func (exp *InfixExpression) Pos() token.Position {
+	return exp.Left.Pos()
+}
+
+func (exp *InfixExpression) End() token.Position {
+	return exp.Right.End()
+}
+
 func (exp *InfixExpression) String() string {
 	var out strings.Builder
 	out.WriteString("(")
@@ -85,26 +119,62 @@ func (exp *IfExpression) TokenLiteral() string {
 	return exp.Token.Literal
 }
 
+func (exp *IfExpression) Pos() token.Position {
+	return exp.Token.Pos
+}
+
+func (exp *IfExpression) End() token.Position {
+	if exp.Alternative != nil {
+		return exp.Alternative.End()
+	}
+	return exp.Consequence.End()
+}
+
 func (exp IfExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("if")
+	out.WriteString("if (")
 	out.WriteString(exp.Condition.String())
-	out.WriteString(" ")
+	out.WriteString(") ")
 	out.WriteString(exp.Consequence.String())
 
 	if exp.Alternative != nil {
-		out.WriteString("else ")
+		out.WriteString(" else ")
 		out.WriteString(exp.Alternative.String())
 	}
 
 	return out.String()
 }
 
+// NamedArgument passes Value to the parameter called Name, as in
+// add(1, y: 2).
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func (arg *NamedArgument) TokenLiteral() string {
+	return arg.Name.TokenLiteral()
+}
+
+func (arg *NamedArgument) Pos() token.Position {
+	return arg.Name.Pos()
+}
+
+func (arg *NamedArgument) End() token.Position {
+	return arg.Value.End()
+}
+
+func (arg *NamedArgument) String() string {
+	return arg.Name.String() + ": " + arg.Value.String()
+}
+
 type CallExpression struct {
-	Token     token.Token
-	Function  Expression
-	Arguments []Expression
+	Token          token.Token
+	Function       Expression
+	Arguments      []Expression
+	NamedArguments []*NamedArgument
+	RightParen     token.Token
 }
 
 func (exp *CallExpression) expressionNode() {
@@ -114,6 +184,14 @@ func (exp *CallExpression) TokenLiteral() string {
 	return exp.Token.Literal
 }
 
+func (exp *CallExpression) Pos() token.Position {
+	return exp.Function.Pos()
+}
+
+func (exp *CallExpression) End() token.Position {
+	return tokenEnd(exp.RightParen)
+}
+
 func (exp *CallExpression) String() string {
 	out := strings.Builder{}
 
@@ -121,6 +199,9 @@ func (exp *CallExpression) String() string {
 	for _, arg := range exp.Arguments {
 		arguments = append(arguments, arg.String())
 	}
+	for _, arg := range exp.NamedArguments {
+		arguments = append(arguments, arg.String())
+	}
 
 	out.WriteString(exp.Function.String())
 	out.WriteString("(")
@@ -129,3 +210,151 @@ func (exp *CallExpression) String() string {
 
 	return out.String()
 }
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+func (arm *MatchArm) TokenLiteral() string {
+	return arm.Pattern.TokenLiteral()
+}
+
+func (arm *MatchArm) Pos() token.Position {
+	return arm.Pattern.Pos()
+}
+
+func (arm *MatchArm) End() token.Position {
+	return arm.Body.End()
+}
+
+func (arm *MatchArm) String() string {
+	var out strings.Builder
+
+	out.WriteString(arm.Pattern.String())
+	if arm.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(arm.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(arm.Body.String())
+
+	return out.String()
+}
+
+type MatchExpression struct {
+	Token      token.Token
+	Subject    Expression
+	Arms       []*MatchArm
+	RightBrace token.Token
+}
+
+func (exp *MatchExpression) expressionNode() {
+}
+
+func (exp *MatchExpression) TokenLiteral() string {
+	return exp.Token.Literal
+}
+
+func (exp *MatchExpression) Pos() token.Position {
+	return exp.Token.Pos
+}
+
+func (exp *MatchExpression) End() token.Position {
+	return tokenEnd(exp.RightBrace)
+}
+
+func (exp *MatchExpression) String() string {
+	out := strings.Builder{}
+
+	arms := []string{}
+	for _, arm := range exp.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match(")
+	out.WriteString(exp.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type MemberExpression struct {
+	Token    token.Token
+	Object   Expression
+	Property *Identifier
+}
+
+func (exp *MemberExpression) expressionNode() {
+}
+
+func (exp *MemberExpression) TokenLiteral() string {
+	return exp.Token.Literal
+}
+
+func (exp *MemberExpression) Pos() token.Position {
+	return exp.Object.Pos()
+}
+
+func (exp *MemberExpression) End() token.Position {
+	return exp.Property.End()
+}
+
+func (exp *MemberExpression) String() string {
+	return exp.Object.String() + "." + exp.Property.String()
+}
+
+// TryExpression evaluates Block, handing an error raised by it to Catch with
+// the error bound to Parameter. Finally runs after both, whatever happened.
+type TryExpression struct {
+	Token     token.Token
+	Block     *BlockStatement
+	Parameter Pattern
+	Catch     *BlockStatement
+	Finally   *BlockStatement
+}
+
+func (exp *TryExpression) expressionNode() {
+}
+
+func (exp *TryExpression) TokenLiteral() string {
+	return exp.Token.Literal
+}
+
+func (exp *TryExpression) Pos() token.Position {
+	return exp.Token.Pos
+}
+
+func (exp *TryExpression) End() token.Position {
+	if exp.Finally != nil {
+		return exp.Finally.End()
+	}
+	if exp.Catch != nil {
+		return exp.Catch.End()
+	}
+	return exp.Block.End()
+}
+
+func (exp *TryExpression) String() string {
+	var out strings.Builder
+
+	out.WriteString("try ")
+	out.WriteString(exp.Block.String())
+
+	if exp.Catch != nil {
+		out.WriteString(" catch(")
+		out.WriteString(exp.Parameter.String())
+		out.WriteString(") ")
+		out.WriteString(exp.Catch.String())
+	}
+
+	if exp.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(exp.Finally.String())
+	}
+
+	return out.String()
+}
@@ -0,0 +1,704 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Nodes are encoded in JSON as objects whose "kind" is the name of their
+// type, whose "pos" is the position of their token and whose "end" is the
+// position just after them. The other members are the fields of the node,
+// named as in Go but starting with a lower case letter. Optional children
+// that are missing are left out, and hash pairs are objects with a "key"
+// and a "value".
+
+func (p *Program) MarshalJSON() ([]byte, error)                 { return marshalNode(p) }
+func (ls *LetStatement) MarshalJSON() ([]byte, error)           { return marshalNode(ls) }
+func (rs *ReturnStatement) MarshalJSON() ([]byte, error)        { return marshalNode(rs) }
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error)    { return marshalNode(es) }
+func (bs *BlockStatement) MarshalJSON() ([]byte, error)         { return marshalNode(bs) }
+func (is *ImportStatement) MarshalJSON() ([]byte, error)        { return marshalNode(is) }
+func (es *ExportStatement) MarshalJSON() ([]byte, error)        { return marshalNode(es) }
+func (ts *ThrowStatement) MarshalJSON() ([]byte, error)         { return marshalNode(ts) }
+func (i *Identifier) MarshalJSON() ([]byte, error)              { return marshalNode(i) }
+func (literal *IntegerLiteral) MarshalJSON() ([]byte, error)    { return marshalNode(literal) }
+func (boolean *BooleanLiteral) MarshalJSON() ([]byte, error)    { return marshalNode(boolean) }
+func (literal *StringLiteral) MarshalJSON() ([]byte, error)     { return marshalNode(literal) }
+func (function *FunctionLiteral) MarshalJSON() ([]byte, error)  { return marshalNode(function) }
+func (array *ArrayLiteral) MarshalJSON() ([]byte, error)        { return marshalNode(array) }
+func (hash *HashLiteral) MarshalJSON() ([]byte, error)          { return marshalNode(hash) }
+func (exp *PrefixExpression) MarshalJSON() ([]byte, error)      { return marshalNode(exp) }
+func (exp *InfixExpression) MarshalJSON() ([]byte, error)       { return marshalNode(exp) }
+func (exp *IfExpression) MarshalJSON() ([]byte, error)          { return marshalNode(exp) }
+func (arg *NamedArgument) MarshalJSON() ([]byte, error)         { return marshalNode(arg) }
+func (exp *CallExpression) MarshalJSON() ([]byte, error)        { return marshalNode(exp) }
+func (arm *MatchArm) MarshalJSON() ([]byte, error)              { return marshalNode(arm) }
+func (exp *MatchExpression) MarshalJSON() ([]byte, error)       { return marshalNode(exp) }
+func (exp *MemberExpression) MarshalJSON() ([]byte, error)      { return marshalNode(exp) }
+func (exp *TryExpression) MarshalJSON() ([]byte, error)         { return marshalNode(exp) }
+func (pattern *LiteralPattern) MarshalJSON() ([]byte, error)    { return marshalNode(pattern) }
+func (pattern *IdentifierPattern) MarshalJSON() ([]byte, error) { return marshalNode(pattern) }
+func (pattern *WildcardPattern) MarshalJSON() ([]byte, error)   { return marshalNode(pattern) }
+func (pattern *RestPattern) MarshalJSON() ([]byte, error)       { return marshalNode(pattern) }
+func (pattern *DefaultPattern) MarshalJSON() ([]byte, error)    { return marshalNode(pattern) }
+func (pattern *ArrayPattern) MarshalJSON() ([]byte, error)      { return marshalNode(pattern) }
+func (pattern *HashPattern) MarshalJSON() ([]byte, error)       { return marshalNode(pattern) }
+
+// UnmarshalJSON decodes a program encoded by MarshalJSON.
+func (p *Program) UnmarshalJSON(data []byte) error {
+	node, err := UnmarshalNode(data)
+	if err != nil {
+		return err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("ast: expected Program, got %T", node)
+	}
+	*p = *program
+	return nil
+}
+
+// UnmarshalNode decodes a node of any type encoded by its MarshalJSON
+// method. The tokens of the nodes are rebuilt from their kind, values and
+// positions.
+func UnmarshalNode(data []byte) (Node, error) {
+	d := &decoder{}
+	node := d.node(data)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return node, nil
+}
+
+// jsonObject is a JSON object whose members are encoded in order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	name  string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	for i, member := range o {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(strconv.Quote(member.name))
+		out.WriteString(":")
+		out.Write(value)
+	}
+	out.WriteString("}")
+
+	return out.Bytes(), nil
+}
+
+func marshalNode(node Node) ([]byte, error) {
+	return json.Marshal(jsonObject(members(node)))
+}
+
+// members returns the members of the JSON object encoding node.
+func members(node Node) []jsonMember {
+	members := fields(node)
+	if len(members) > 1 && members[1].name == "pos" {
+		end := jsonMember{"end", node.End()}
+		members = append(members[:2], append([]jsonMember{end}, members[2:]...)...)
+	}
+	return members
+}
+
+// fields returns the kind, the position of the token and the fields of
+// node.
+func fields(node Node) []jsonMember {
+	kind := jsonMember{"kind", fmt.Sprintf("%T", node)[len("*ast."):]}
+	pos := func(tok token.Token) jsonMember { return jsonMember{"pos", tok.Pos} }
+
+	switch node := node.(type) {
+	case *Program:
+		return []jsonMember{kind, {"statements", statementList(node.Statements)}}
+	case *LetStatement:
+		if node.Pattern != nil {
+			return []jsonMember{kind, pos(node.Token), {"pattern", node.Pattern}, {"value", node.Value}}
+		}
+		return []jsonMember{kind, pos(node.Token), {"name", node.Name}, {"value", node.Value}}
+	case *ReturnStatement:
+		members := []jsonMember{kind, pos(node.Token)}
+		if node.ReturnValue != nil {
+			members = append(members, jsonMember{"returnValue", node.ReturnValue})
+		}
+		return members
+	case *ExpressionStatement:
+		return []jsonMember{kind, pos(node.Token), {"expression", node.Expression}}
+	case *BlockStatement:
+		return []jsonMember{kind, pos(node.Token), {"statements", statementList(node.Statements)}}
+	case *ImportStatement:
+		return []jsonMember{kind, pos(node.Token), {"path", node.Path}, {"alias", node.Alias}}
+	case *ExportStatement:
+		return []jsonMember{kind, pos(node.Token), {"statement", node.Statement}}
+	case *ThrowStatement:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *Identifier:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *IntegerLiteral:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *BooleanLiteral:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *StringLiteral:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *FunctionLiteral:
+		return []jsonMember{kind, pos(node.Token),
+			{"parameters", patternList(node.Parameters)}, {"body", node.Body}}
+	case *ArrayLiteral:
+		return []jsonMember{kind, pos(node.Token), {"elements", expressionList(node.Elements)}}
+	case *HashLiteral:
+		pairs := make([]jsonObject, len(node.Pairs))
+		for i, pair := range node.Pairs {
+			pairs[i] = jsonObject{{"key", pair.Key}, {"value", pair.Value}}
+		}
+		return []jsonMember{kind, pos(node.Token), {"pairs", pairs}}
+	case *PrefixExpression:
+		return []jsonMember{kind, pos(node.Token), {"operator", node.Operator}, {"right", node.Right}}
+	case *InfixExpression:
+		return []jsonMember{kind, pos(node.Token),
+			{"operator", node.Operator}, {"left", node.Left}, {"right", node.Right}}
+	case *IfExpression:
+		members := []jsonMember{kind, pos(node.Token),
+			{"condition", node.Condition}, {"consequence", node.Consequence}}
+		if node.Alternative != nil {
+			members = append(members, jsonMember{"alternative", node.Alternative})
+		}
+		return members
+	case *NamedArgument:
+		return []jsonMember{kind, {"name", node.Name}, {"value", node.Value}}
+	case *CallExpression:
+		named := make([]Node, len(node.NamedArguments))
+		for i, arg := range node.NamedArguments {
+			named[i] = arg
+		}
+		return []jsonMember{kind, pos(node.Token), {"function", node.Function},
+			{"arguments", expressionList(node.Arguments)}, {"namedArguments", named}}
+	case *MatchArm:
+		members := []jsonMember{kind, {"pattern", node.Pattern}}
+		if node.Guard != nil {
+			members = append(members, jsonMember{"guard", node.Guard})
+		}
+		return append(members, jsonMember{"body", node.Body})
+	case *MatchExpression:
+		arms := make([]Node, len(node.Arms))
+		for i, arm := range node.Arms {
+			arms[i] = arm
+		}
+		return []jsonMember{kind, pos(node.Token), {"subject", node.Subject}, {"arms", arms}}
+	case *MemberExpression:
+		return []jsonMember{kind, pos(node.Token), {"object", node.Object}, {"property", node.Property}}
+	case *TryExpression:
+		members := []jsonMember{kind, pos(node.Token), {"block", node.Block}}
+		if node.Catch != nil {
+			members = append(members, jsonMember{"parameter", node.Parameter}, jsonMember{"catch", node.Catch})
+		}
+		if node.Finally != nil {
+			members = append(members, jsonMember{"finally", node.Finally})
+		}
+		return members
+	case *LiteralPattern:
+		return []jsonMember{kind, pos(node.Token), {"value", node.Value}}
+	case *IdentifierPattern:
+		return []jsonMember{kind, pos(node.Token), {"name", node.Name}}
+	case *WildcardPattern:
+		return []jsonMember{kind, pos(node.Token)}
+	case *RestPattern:
+		return []jsonMember{kind, pos(node.Token), {"name", node.Name}}
+	case *DefaultPattern:
+		return []jsonMember{kind, pos(node.Token), {"target", node.Target}, {"default", node.Default}}
+	case *ArrayPattern:
+		return []jsonMember{kind, pos(node.Token), {"elements", patternList(node.Elements)}}
+	case *HashPattern:
+		pairs := make([]jsonObject, len(node.Pairs))
+		for i, pair := range node.Pairs {
+			pairs[i] = jsonObject{{"key", pair.Key}, {"value", pair.Value}}
+		}
+		return []jsonMember{kind, pos(node.Token), {"pairs", pairs}}
+	default:
+		return []jsonMember{kind}
+	}
+}
+
+// The lists are converted to []Node so that an empty list is encoded as []
+// rather than null.
+
+func statementList(statements []Statement) []Node {
+	nodes := make([]Node, len(statements))
+	for i, stmt := range statements {
+		nodes[i] = stmt
+	}
+	return nodes
+}
+
+func expressionList(expressions []Expression) []Node {
+	nodes := make([]Node, len(expressions))
+	for i, exp := range expressions {
+		nodes[i] = exp
+	}
+	return nodes
+}
+
+func patternList(patterns []Pattern) []Node {
+	nodes := make([]Node, len(patterns))
+	for i, pattern := range patterns {
+		nodes[i] = pattern
+	}
+	return nodes
+}
+
+// decoder builds nodes from their JSON encoding, keeping the first error.
+type decoder struct {
+	err error
+}
+
+type jsonNode map[string]json.RawMessage
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("ast: "+format, args...)
+	}
+}
+
+func (d *decoder) decode(data json.RawMessage, v interface{}) {
+	if d.err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		d.err = fmt.Errorf("ast: %w", err)
+	}
+}
+
+func (d *decoder) node(data json.RawMessage) Node {
+	o := jsonNode{}
+	d.decode(data, &o)
+	if d.err != nil {
+		return nil
+	}
+
+	var kind string
+	d.decode(o["kind"], &kind)
+	pos := token.Position{}
+	if _, ok := o["pos"]; ok {
+		d.decode(o["pos"], &pos)
+	}
+	end := token.Position{}
+	if _, ok := o["end"]; ok {
+		d.decode(o["end"], &end)
+	}
+	tok := func(tokenType token.TokenType, literal string) token.Token {
+		return token.Token{Type: tokenType, Literal: literal, Pos: pos}
+	}
+	// closing returns the bracket ending the node.
+	closing := func(tokenType token.TokenType, literal string) token.Token {
+		return token.Token{Type: tokenType, Literal: literal, Pos: token.Position{Line: end.Line, Column: end.Column - 1}}
+	}
+
+	switch kind {
+	case "Program":
+		return &Program{Statements: d.statements(o, "statements")}
+	case "LetStatement":
+		stmt := &LetStatement{Token: tok(token.Let, "let")}
+		if _, ok := o["pattern"]; ok {
+			stmt.Pattern = d.pattern(o, "pattern")
+		} else {
+			stmt.Name = d.identifier(o, "name")
+		}
+		stmt.Value = d.expression(o, "value")
+		return stmt
+	case "ReturnStatement":
+		stmt := &ReturnStatement{Token: tok(token.Return, "return")}
+		if _, ok := o["returnValue"]; ok {
+			stmt.ReturnValue = d.expression(o, "returnValue")
+		}
+		return stmt
+	case "ExpressionStatement":
+		stmt := &ExpressionStatement{Expression: d.expression(o, "expression")}
+		// The statement starts with the first token of its expression,
+		// unless the expression is grouped.
+		stmt.Token = tok(token.LeftParen, "(")
+		if stmt.Expression != nil {
+			if first := firstToken(stmt.Expression); first.Pos == pos {
+				stmt.Token = first
+			}
+		}
+		return stmt
+	case "BlockStatement":
+		return &BlockStatement{
+			Token:      tok(token.LeftBrace, "{"),
+			Statements: d.statements(o, "statements"),
+			RightBrace: closing(token.RightBrace, "}"),
+		}
+	case "ImportStatement":
+		return &ImportStatement{
+			Token: tok(token.Import, "import"),
+			Path:  d.stringLiteral(o, "path"),
+			Alias: d.identifier(o, "alias"),
+		}
+	case "ExportStatement":
+		stmt := &ExportStatement{Token: tok(token.Export, "export")}
+		if let, ok := d.child(o, "statement").(*LetStatement); ok {
+			stmt.Statement = let
+		} else {
+			d.fail("expected a LetStatement in statement of ExportStatement")
+		}
+		return stmt
+	case "ThrowStatement":
+		return &ThrowStatement{Token: tok(token.Throw, "throw"), Value: d.expression(o, "value")}
+	case "Identifier":
+		identifier := &Identifier{}
+		d.decode(o["value"], &identifier.Value)
+		identifier.Token = tok(token.Ident, identifier.Value)
+		return identifier
+	case "IntegerLiteral":
+		literal := &IntegerLiteral{}
+		d.decode(o["value"], &literal.Value)
+		literal.Token = tok(token.Int, strconv.FormatInt(literal.Value, 10))
+		return literal
+	case "BooleanLiteral":
+		boolean := &BooleanLiteral{}
+		d.decode(o["value"], &boolean.Value)
+		if boolean.Value {
+			boolean.Token = tok(token.True, "true")
+		} else {
+			boolean.Token = tok(token.False, "false")
+		}
+		return boolean
+	case "StringLiteral":
+		literal := &StringLiteral{}
+		d.decode(o["value"], &literal.Value)
+		literal.Token = tok(token.String, literal.Value)
+		return literal
+	case "FunctionLiteral":
+		return &FunctionLiteral{
+			Token:      tok(token.Function, "fn"),
+			Parameters: d.patterns(o, "parameters"),
+			Body:       d.block(o, "body"),
+		}
+	case "ArrayLiteral":
+		return &ArrayLiteral{
+			Token:        tok(token.LeftBracket, "["),
+			Elements:     d.expressions(o, "elements"),
+			RightBracket: closing(token.RightBracket, "]"),
+		}
+	case "HashLiteral":
+		hash := &HashLiteral{
+			Token:      tok(token.LeftBrace, "{"),
+			Pairs:      []HashPair{},
+			RightBrace: closing(token.RightBrace, "}"),
+		}
+		for _, pair := range d.list(o, "pairs") {
+			hash.Pairs = append(hash.Pairs, HashPair{
+				Key:   d.expression(pair, "key"),
+				Value: d.expression(pair, "value"),
+			})
+		}
+		return hash
+	case "PrefixExpression":
+		exp := &PrefixExpression{}
+		d.decode(o["operator"], &exp.Operator)
+		exp.Token = tok(operatorType(exp.Operator), exp.Operator)
+		exp.Right = d.expression(o, "right")
+		return exp
+	case "InfixExpression":
+		exp := &InfixExpression{}
+		d.decode(o["operator"], &exp.Operator)
+		exp.Token = tok(operatorType(exp.Operator), exp.Operator)
+		exp.Left = d.expression(o, "left")
+		exp.Right = d.expression(o, "right")
+		return exp
+	case "IfExpression":
+		exp := &IfExpression{
+			Token:       tok(token.If, "if"),
+			Condition:   d.expression(o, "condition"),
+			Consequence: d.block(o, "consequence"),
+		}
+		if _, ok := o["alternative"]; ok {
+			exp.Alternative = d.block(o, "alternative")
+		}
+		return exp
+	case "NamedArgument":
+		return &NamedArgument{Name: d.identifier(o, "name"), Value: d.expression(o, "value")}
+	case "CallExpression":
+		exp := &CallExpression{
+			Token:          tok(token.LeftParen, "("),
+			Function:       d.expression(o, "function"),
+			Arguments:      d.expressions(o, "arguments"),
+			NamedArguments: []*NamedArgument{},
+			RightParen:     closing(token.RightParen, ")"),
+		}
+		for _, node := range d.nodes(o, "namedArguments") {
+			if named, ok := node.(*NamedArgument); ok {
+				exp.NamedArguments = append(exp.NamedArguments, named)
+			} else {
+				d.fail("expected a NamedArgument in namedArguments of CallExpression")
+			}
+		}
+		return exp
+	case "MatchArm":
+		arm := &MatchArm{Pattern: d.pattern(o, "pattern")}
+		if _, ok := o["guard"]; ok {
+			arm.Guard = d.expression(o, "guard")
+		}
+		arm.Body = d.expression(o, "body")
+		return arm
+	case "MatchExpression":
+		exp := &MatchExpression{
+			Token:      tok(token.Match, "match"),
+			Subject:    d.expression(o, "subject"),
+			Arms:       []*MatchArm{},
+			RightBrace: closing(token.RightBrace, "}"),
+		}
+		for _, node := range d.nodes(o, "arms") {
+			if arm, ok := node.(*MatchArm); ok {
+				exp.Arms = append(exp.Arms, arm)
+			} else {
+				d.fail("expected a MatchArm in arms of MatchExpression")
+			}
+		}
+		return exp
+	case "MemberExpression":
+		return &MemberExpression{
+			Token:    tok(token.Dot, "."),
+			Object:   d.expression(o, "object"),
+			Property: d.identifier(o, "property"),
+		}
+	case "TryExpression":
+		exp := &TryExpression{Token: tok(token.Try, "try"), Block: d.block(o, "block")}
+		if _, ok := o["catch"]; ok {
+			exp.Parameter = d.pattern(o, "parameter")
+			exp.Catch = d.block(o, "catch")
+		}
+		if _, ok := o["finally"]; ok {
+			exp.Finally = d.block(o, "finally")
+		}
+		return exp
+	case "LiteralPattern":
+		pattern := &LiteralPattern{Value: d.expression(o, "value")}
+		if pattern.Value != nil {
+			pattern.Token = firstToken(pattern.Value)
+		}
+		return pattern
+	case "IdentifierPattern":
+		pattern := &IdentifierPattern{Name: d.identifier(o, "name")}
+		if pattern.Name != nil {
+			pattern.Token = pattern.Name.Token
+		}
+		return pattern
+	case "WildcardPattern":
+		return &WildcardPattern{Token: tok(token.Ident, "_")}
+	case "RestPattern":
+		return &RestPattern{Token: tok(token.Ellipsis, "..."), Name: d.identifier(o, "name")}
+	case "DefaultPattern":
+		return &DefaultPattern{
+			Token:   tok(token.Assign, "="),
+			Target:  d.pattern(o, "target"),
+			Default: d.expression(o, "default"),
+		}
+	case "ArrayPattern":
+		return &ArrayPattern{
+			Token:        tok(token.LeftBracket, "["),
+			Elements:     d.patterns(o, "elements"),
+			RightBracket: closing(token.RightBracket, "]"),
+		}
+	case "HashPattern":
+		pattern := &HashPattern{
+			Token:      tok(token.LeftBrace, "{"),
+			Pairs:      []HashPatternPair{},
+			RightBrace: closing(token.RightBrace, "}"),
+		}
+		for _, pair := range d.list(o, "pairs") {
+			key, value := d.expression(pair, "key"), d.pattern(pair, "value")
+			// The key of the shorthand {name} is the token of the name.
+			if str, ok := key.(*StringLiteral); ok {
+				if name, ok := value.(*IdentifierPattern); ok && name.Token.Pos == str.Token.Pos {
+					str.Token = name.Token
+				}
+			}
+			pattern.Pairs = append(pattern.Pairs, HashPatternPair{Key: key, Value: value})
+		}
+		return pattern
+	default:
+		d.fail("unknown node kind %q", kind)
+		return nil
+	}
+}
+
+// child decodes the node in the member name of o, which must be present.
+func (d *decoder) child(o jsonNode, name string) Node {
+	data, ok := o[name]
+	if !ok || string(data) == "null" {
+		d.fail("missing %s in %s", name, o.kind())
+		return nil
+	}
+	return d.node(data)
+}
+
+func (o jsonNode) kind() string {
+	var kind string
+	json.Unmarshal(o["kind"], &kind)
+	if kind == "" {
+		return "pair"
+	}
+	return kind
+}
+
+// list decodes the array of objects in the member name of o, such as the
+// pairs of a hash.
+func (d *decoder) list(o jsonNode, name string) []jsonNode {
+	list := []jsonNode{}
+	d.decode(o[name], &list)
+	return list
+}
+
+// nodes decodes the array of nodes in the member name of o.
+func (d *decoder) nodes(o jsonNode, name string) []Node {
+	raw := []json.RawMessage{}
+	d.decode(o[name], &raw)
+
+	nodes := make([]Node, len(raw))
+	for i, data := range raw {
+		nodes[i] = d.node(data)
+	}
+	return nodes
+}
+
+func (d *decoder) statements(o jsonNode, name string) []Statement {
+	statements := []Statement{}
+	for _, node := range d.nodes(o, name) {
+		if stmt, ok := node.(Statement); ok {
+			statements = append(statements, stmt)
+		} else {
+			d.fail("expected a statement in %s of %s", name, o.kind())
+		}
+	}
+	return statements
+}
+
+func (d *decoder) expressions(o jsonNode, name string) []Expression {
+	expressions := []Expression{}
+	for _, node := range d.nodes(o, name) {
+		if exp, ok := node.(Expression); ok {
+			expressions = append(expressions, exp)
+		} else {
+			d.fail("expected an expression in %s of %s", name, o.kind())
+		}
+	}
+	return expressions
+}
+
+func (d *decoder) patterns(o jsonNode, name string) []Pattern {
+	patterns := []Pattern{}
+	for _, node := range d.nodes(o, name) {
+		if pattern, ok := node.(Pattern); ok {
+			patterns = append(patterns, pattern)
+		} else {
+			d.fail("expected a pattern in %s of %s", name, o.kind())
+		}
+	}
+	return patterns
+}
+
+func (d *decoder) expression(o jsonNode, name string) Expression {
+	exp, ok := d.child(o, name).(Expression)
+	if !ok {
+		d.fail("expected an expression in %s of %s", name, o.kind())
+	}
+	return exp
+}
+
+func (d *decoder) pattern(o jsonNode, name string) Pattern {
+	pattern, ok := d.child(o, name).(Pattern)
+	if !ok {
+		d.fail("expected a pattern in %s of %s", name, o.kind())
+	}
+	return pattern
+}
+
+func (d *decoder) block(o jsonNode, name string) *BlockStatement {
+	block, ok := d.child(o, name).(*BlockStatement)
+	if !ok {
+		d.fail("expected a BlockStatement in %s of %s", name, o.kind())
+	}
+	return block
+}
+
+func (d *decoder) identifier(o jsonNode, name string) *Identifier {
+	identifier, ok := d.child(o, name).(*Identifier)
+	if !ok {
+		d.fail("expected an Identifier in %s of %s", name, o.kind())
+	}
+	return identifier
+}
+
+func (d *decoder) stringLiteral(o jsonNode, name string) *StringLiteral {
+	literal, ok := d.child(o, name).(*StringLiteral)
+	if !ok {
+		d.fail("expected a StringLiteral in %s of %s", name, o.kind())
+	}
+	return literal
+}
+
+func operatorType(operator string) token.TokenType {
+	switch operator {
+	case "==":
+		return token.Equal
+	case "!=":
+		return token.NotEqual
+	}
+	for ch, tokenType := range token.SingleToken {
+		if string(ch) == operator {
+			return tokenType
+		}
+	}
+	return token.Illegal
+}
+
+// firstToken returns the leftmost token of exp, ignoring parentheses.
+func firstToken(exp Expression) token.Token {
+	switch exp := exp.(type) {
+	case *Identifier:
+		return exp.Token
+	case *IntegerLiteral:
+		return exp.Token
+	case *BooleanLiteral:
+		return exp.Token
+	case *StringLiteral:
+		return exp.Token
+	case *FunctionLiteral:
+		return exp.Token
+	case *ArrayLiteral:
+		return exp.Token
+	case *HashLiteral:
+		return exp.Token
+	case *PrefixExpression:
+		return exp.Token
+	case *InfixExpression:
+		return firstToken(exp.Left)
+	case *IfExpression:
+		return exp.Token
+	case *CallExpression:
+		return firstToken(exp.Function)
+	case *MatchExpression:
+		return exp.Token
+	case *MemberExpression:
+		return firstToken(exp.Object)
+	case *TryExpression:
+		return exp.Token
+	default:
+		return token.Token{}
+	}
+}
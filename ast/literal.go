@@ -16,6 +16,12 @@ func (literal *IntegerLiteral) expressionNode() {}
 func (literal *IntegerLiteral) TokenLiteral() string {
 	return literal.Token.Literal
 }
+func (literal *IntegerLiteral) Pos() token.Position {
+	return literal.Token.Pos
+}
+func (literal *IntegerLiteral) End() token.Position {
+	return tokenEnd(literal.Token)
+}
 func (literal *IntegerLiteral) String() string {
 	return literal.Token.Literal
 }
@@ -29,13 +35,19 @@ func (boolean *BooleanLiteral) expressionNode() {}
 func (boolean *BooleanLiteral) TokenLiteral() string {
 	return boolean.Token.Literal
 }
+func (boolean *BooleanLiteral) Pos() token.Position {
+	return boolean.Token.Pos
+}
+func (boolean *BooleanLiteral) End() token.Position {
+	return tokenEnd(boolean.Token)
+}
 func (boolean *BooleanLiteral) String() string {
 	return boolean.Token.Literal
 }
 
 type FunctionLiteral struct {
 	Token      token.Token
-	Parameters []*Identifier
+	Parameters []Pattern
 	Body       *BlockStatement
 }
 
@@ -43,6 +55,12 @@ func (function *FunctionLiteral) expressionNode() {}
 func (function *FunctionLiteral) TokenLiteral() string {
 	return function.Token.Literal
 }
+func (function *FunctionLiteral) Pos() token.Position {
+	return function.Token.Pos
+}
+func (function *FunctionLiteral) End() token.Position {
+	return function.Body.End()
+}
 func (function *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -54,8 +72,94 @@ func (function *FunctionLiteral) String() string {
 	out.WriteString(function.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(")")
+	out.WriteString(") ")
 	out.WriteString(function.Body.String())
 
 	return out.String()
 }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (literal *StringLiteral) expressionNode() {}
+func (literal *StringLiteral) TokenLiteral() string {
+	return literal.Token.Literal
+}
+func (literal *StringLiteral) Pos() token.Position {
+	return literal.Token.Pos
+}
+func (literal *StringLiteral) End() token.Position {
+	return tokenEnd(literal.Token)
+}
+func (literal *StringLiteral) String() string {
+	return "\"" + literal.Value + "\""
+}
+
+type ArrayLiteral struct {
+	Token        token.Token
+	Elements     []Expression
+	RightBracket token.Token
+}
+
+func (array *ArrayLiteral) expressionNode() {}
+func (array *ArrayLiteral) TokenLiteral() string {
+	return array.Token.Literal
+}
+func (array *ArrayLiteral) Pos() token.Position {
+	return array.Token.Pos
+}
+func (array *ArrayLiteral) End() token.Position {
+	return tokenEnd(array.RightBracket)
+}
+func (array *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range array.Elements {
+		elements = append(elements, e.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	Token      token.Token
+	Pairs      []HashPair
+	RightBrace token.Token
+}
+
+func (hash *HashLiteral) expressionNode() {}
+func (hash *HashLiteral) TokenLiteral() string {
+	return hash.Token.Literal
+}
+func (hash *HashLiteral) Pos() token.Position {
+	return hash.Token.Pos
+}
+func (hash *HashLiteral) End() token.Position {
+	return tokenEnd(hash.RightBrace)
+}
+func (hash *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
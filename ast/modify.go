@@ -0,0 +1,206 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc returns the node that replaces node in the tree, which may be
+// node itself.
+type ModifierFunc func(Node) Node
+
+// Modify rewrites a tree bottom-up: the children of node are modified first
+// and stored back into node, and then node itself is passed to modifier.
+// It returns the result of modifier for node. Optional children that are
+// absent are left nil. A replacement must fit where the node it replaces
+// was, so that a block, for example, can only be replaced by a block;
+// Modify panics otherwise.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		modifyStatements(n.Statements, modifier)
+
+	// Statements
+	case *LetStatement:
+		if n.Pattern != nil {
+			n.Pattern = modifyPattern(n.Pattern, modifier)
+		} else {
+			n.Name = modifyIdentifier(n.Name, modifier)
+		}
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			n.ReturnValue = modifyExpression(n.ReturnValue, modifier)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			n.Expression = modifyExpression(n.Expression, modifier)
+		}
+	case *BlockStatement:
+		modifyStatements(n.Statements, modifier)
+	case *ImportStatement:
+		replaced := Modify(n.Path, modifier)
+		path, ok := replaced.(*StringLiteral)
+		if !ok {
+			badReplacement(replaced, "*ast.StringLiteral")
+		}
+		n.Path = path
+		n.Alias = modifyIdentifier(n.Alias, modifier)
+	case *ExportStatement:
+		replaced := Modify(n.Statement, modifier)
+		stmt, ok := replaced.(*LetStatement)
+		if !ok {
+			badReplacement(replaced, "*ast.LetStatement")
+		}
+		n.Statement = stmt
+	case *ThrowStatement:
+		n.Value = modifyExpression(n.Value, modifier)
+
+	// Expressions
+	case *PrefixExpression:
+		n.Right = modifyExpression(n.Right, modifier)
+	case *InfixExpression:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Right = modifyExpression(n.Right, modifier)
+	case *IfExpression:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		if n.Alternative != nil {
+			n.Alternative = modifyBlock(n.Alternative, modifier)
+		}
+	case *FunctionLiteral:
+		modifyPatterns(n.Parameters, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *CallExpression:
+		n.Function = modifyExpression(n.Function, modifier)
+		modifyExpressions(n.Arguments, modifier)
+		for i, arg := range n.NamedArguments {
+			replaced := Modify(arg, modifier)
+			named, ok := replaced.(*NamedArgument)
+			if !ok {
+				badReplacement(replaced, "*ast.NamedArgument")
+			}
+			n.NamedArguments[i] = named
+		}
+	case *NamedArgument:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ArrayLiteral:
+		modifyExpressions(n.Elements, modifier)
+	case *HashLiteral:
+		for i := range n.Pairs {
+			n.Pairs[i].Key = modifyExpression(n.Pairs[i].Key, modifier)
+			n.Pairs[i].Value = modifyExpression(n.Pairs[i].Value, modifier)
+		}
+	case *MatchExpression:
+		n.Subject = modifyExpression(n.Subject, modifier)
+		for i, arm := range n.Arms {
+			replaced := Modify(arm, modifier)
+			modified, ok := replaced.(*MatchArm)
+			if !ok {
+				badReplacement(replaced, "*ast.MatchArm")
+			}
+			n.Arms[i] = modified
+		}
+	case *MatchArm:
+		n.Pattern = modifyPattern(n.Pattern, modifier)
+		if n.Guard != nil {
+			n.Guard = modifyExpression(n.Guard, modifier)
+		}
+		n.Body = modifyExpression(n.Body, modifier)
+	case *MemberExpression:
+		n.Object = modifyExpression(n.Object, modifier)
+		n.Property = modifyIdentifier(n.Property, modifier)
+	case *TryExpression:
+		n.Block = modifyBlock(n.Block, modifier)
+		if n.Parameter != nil {
+			n.Parameter = modifyPattern(n.Parameter, modifier)
+		}
+		if n.Catch != nil {
+			n.Catch = modifyBlock(n.Catch, modifier)
+		}
+		if n.Finally != nil {
+			n.Finally = modifyBlock(n.Finally, modifier)
+		}
+
+	// Patterns
+	case *LiteralPattern:
+		n.Value = modifyExpression(n.Value, modifier)
+	case *IdentifierPattern:
+		n.Name = modifyIdentifier(n.Name, modifier)
+	case *RestPattern:
+		n.Name = modifyIdentifier(n.Name, modifier)
+	case *DefaultPattern:
+		n.Target = modifyPattern(n.Target, modifier)
+		n.Default = modifyExpression(n.Default, modifier)
+	case *ArrayPattern:
+		modifyPatterns(n.Elements, modifier)
+	case *HashPattern:
+		for i := range n.Pairs {
+			n.Pairs[i].Key = modifyExpression(n.Pairs[i].Key, modifier)
+			n.Pairs[i].Value = modifyPattern(n.Pairs[i].Value, modifier)
+		}
+	}
+
+	return modifier(node)
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) {
+	for i, stmt := range stmts {
+		replaced := Modify(stmt, modifier)
+		modified, ok := replaced.(Statement)
+		if !ok {
+			badReplacement(replaced, "ast.Statement")
+		}
+		stmts[i] = modified
+	}
+}
+
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	replaced := Modify(exp, modifier)
+	modified, ok := replaced.(Expression)
+	if !ok {
+		badReplacement(replaced, "ast.Expression")
+	}
+	return modified
+}
+
+func modifyExpressions(exps []Expression, modifier ModifierFunc) {
+	for i, exp := range exps {
+		exps[i] = modifyExpression(exp, modifier)
+	}
+}
+
+func modifyPattern(pattern Pattern, modifier ModifierFunc) Pattern {
+	replaced := Modify(pattern, modifier)
+	modified, ok := replaced.(Pattern)
+	if !ok {
+		badReplacement(replaced, "ast.Pattern")
+	}
+	return modified
+}
+
+func modifyPatterns(patterns []Pattern, modifier ModifierFunc) {
+	for i, pattern := range patterns {
+		patterns[i] = modifyPattern(pattern, modifier)
+	}
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	replaced := Modify(block, modifier)
+	modified, ok := replaced.(*BlockStatement)
+	if !ok {
+		badReplacement(replaced, "*ast.BlockStatement")
+	}
+	return modified
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	replaced := Modify(ident, modifier)
+	modified, ok := replaced.(*Identifier)
+	if !ok {
+		badReplacement(replaced, "*ast.Identifier")
+	}
+	return modified
+}
+
+func badReplacement(node Node, want string) {
+	panic(fmt.Sprintf("ast.Modify: cannot replace %s with %T", want, node))
+}
@@ -0,0 +1,234 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// LiteralPattern matches a value equal to an integer, boolean or string
+// literal.
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression
+}
+
+func (pattern *LiteralPattern) patternNode() {}
+func (pattern *LiteralPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *LiteralPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *LiteralPattern) End() token.Position {
+	return pattern.Value.End()
+}
+func (pattern *LiteralPattern) String() string {
+	// A pattern cannot start with a parenthesis, so a negative number is
+	// not grouped like other prefix expressions.
+	if prefix, ok := pattern.Value.(*PrefixExpression); ok {
+		return prefix.Operator + prefix.Right.String()
+	}
+	return pattern.Value.String()
+}
+
+// IdentifierPattern matches any value and binds it to Name.
+type IdentifierPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (pattern *IdentifierPattern) patternNode() {}
+func (pattern *IdentifierPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *IdentifierPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *IdentifierPattern) End() token.Position {
+	return pattern.Name.End()
+}
+func (pattern *IdentifierPattern) String() string {
+	return pattern.Name.String()
+}
+
+// WildcardPattern matches any value without binding it.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (pattern *WildcardPattern) patternNode() {}
+func (pattern *WildcardPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *WildcardPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *WildcardPattern) End() token.Position {
+	return tokenEnd(pattern.Token)
+}
+func (pattern *WildcardPattern) String() string {
+	return "_"
+}
+
+// RestPattern collects the remaining elements of an array into Name. It may
+// only appear as the last element of an ArrayPattern or a parameter list.
+type RestPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (pattern *RestPattern) patternNode() {}
+func (pattern *RestPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *RestPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *RestPattern) End() token.Position {
+	return pattern.Name.End()
+}
+func (pattern *RestPattern) String() string {
+	return "..." + pattern.Name.String()
+}
+
+// SplitRest separates a trailing RestPattern, as an ArrayPattern or a
+// parameter list may end with, from the patterns that bind exactly one
+// element each.
+func SplitRest(patterns []Pattern) ([]Pattern, *RestPattern) {
+	if len(patterns) == 0 {
+		return patterns, nil
+	}
+
+	rest, ok := patterns[len(patterns)-1].(*RestPattern)
+	if !ok {
+		return patterns, nil
+	}
+
+	return patterns[:len(patterns)-1], rest
+}
+
+// PatternNames returns the identifiers pattern binds, in source order.
+func PatternNames(pattern Pattern) []*Identifier {
+	switch pattern := pattern.(type) {
+	case *IdentifierPattern:
+		return []*Identifier{pattern.Name}
+	case *RestPattern:
+		return []*Identifier{pattern.Name}
+	case *DefaultPattern:
+		return PatternNames(pattern.Target)
+	case *ArrayPattern:
+		names := []*Identifier{}
+		for _, element := range pattern.Elements {
+			names = append(names, PatternNames(element)...)
+		}
+		return names
+	case *HashPattern:
+		names := []*Identifier{}
+		for _, pair := range pattern.Pairs {
+			names = append(names, PatternNames(pair.Value)...)
+		}
+		return names
+	default:
+		return []*Identifier{}
+	}
+}
+
+// DefaultPattern is a parameter whose Default expression is evaluated at call
+// time when no argument is given for it.
+type DefaultPattern struct {
+	Token   token.Token
+	Target  Pattern
+	Default Expression
+}
+
+func (pattern *DefaultPattern) patternNode() {}
+func (pattern *DefaultPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *DefaultPattern) Pos() token.Position {
+	return pattern.Target.Pos()
+}
+func (pattern *DefaultPattern) End() token.Position {
+	return pattern.Default.End()
+}
+func (pattern *DefaultPattern) String() string {
+	return pattern.Target.String() + " = " + pattern.Default.String()
+}
+
+// ArrayPattern matches an array with exactly as many elements as Elements,
+// or at least as many when the last element is a RestPattern.
+type ArrayPattern struct {
+	Token        token.Token
+	Elements     []Pattern
+	RightBracket token.Token
+}
+
+func (pattern *ArrayPattern) patternNode() {}
+func (pattern *ArrayPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *ArrayPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *ArrayPattern) End() token.Position {
+	return tokenEnd(pattern.RightBracket)
+}
+func (pattern *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range pattern.Elements {
+		elements = append(elements, e.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+// HashPattern matches a hash containing every key in Pairs. Keys that are
+// not listed in the pattern are ignored.
+type HashPattern struct {
+	Token      token.Token
+	Pairs      []HashPatternPair
+	RightBrace token.Token
+}
+
+func (pattern *HashPattern) patternNode() {}
+func (pattern *HashPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+func (pattern *HashPattern) Pos() token.Position {
+	return pattern.Token.Pos
+}
+func (pattern *HashPattern) End() token.Position {
+	return tokenEnd(pattern.RightBrace)
+}
+func (pattern *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range pattern.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
@@ -12,10 +12,13 @@ type Statement interface {
 	statementNode()
 }
 
+// LetStatement binds Value to Name, or destructures it through Pattern
+// when the left-hand side is an array or hash pattern.
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token
+	Name    *Identifier
+	Pattern Pattern
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode() {}
@@ -24,7 +27,18 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *LetStatement) End() token.Position {
+	return ls.Value.End()
+}
+
 func (ls *LetStatement) String() string {
+	if ls.Pattern != nil {
+		return fmt.Sprintf("%s %s = %s;", ls.TokenLiteral(), ls.Pattern.String(), ls.Value.String())
+	}
 	return fmt.Sprintf("%s %s = %s;", ls.TokenLiteral(), ls.Name.Value, ls.Value.String())
 }
 
@@ -39,6 +53,17 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return tokenEnd(rs.Token)
+}
+
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(rs.TokenLiteral() + " ")
@@ -61,6 +86,17 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return tokenEnd(es.Token)
+}
+
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -71,6 +107,7 @@ func (es *ExpressionStatement) String() string {
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	RightBrace token.Token
 }
 
 func (bs *BlockStatement) statementNode() {
@@ -80,12 +117,91 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
-func (bs *BlockStatement) String() string {
-	var out bytes.Buffer
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BlockStatement) End() token.Position {
+	return tokenEnd(bs.RightBrace)
+}
 
-	for _, s := range bs.Statements {
-		out.WriteString(s.String())
+func (bs *BlockStatement) String() string {
+	if len(bs.Statements) == 0 {
+		return "{}"
 	}
+	return "{ " + statementsString(bs.Statements) + " }"
+}
 
-	return out.String()
+// ImportStatement evaluates the module at Path once and binds its exports to
+// Alias, as in import "lib/math.mk" as math;
+type ImportStatement struct {
+	Token token.Token
+	Path  *StringLiteral
+	Alias *Identifier
+}
+
+func (is *ImportStatement) statementNode() {}
+
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *ImportStatement) Pos() token.Position {
+	return is.Token.Pos
+}
+
+func (is *ImportStatement) End() token.Position {
+	return is.Alias.End()
+}
+
+func (is *ImportStatement) String() string {
+	return fmt.Sprintf("%s %s as %s;", is.TokenLiteral(), is.Path.String(), is.Alias.String())
+}
+
+// ExportStatement makes the names bound by Statement visible to the modules
+// importing the current one.
+type ExportStatement struct {
+	Token     token.Token
+	Statement *LetStatement
+}
+
+func (es *ExportStatement) statementNode() {}
+
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+func (es *ExportStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
+func (es *ExportStatement) End() token.Position {
+	return es.Statement.End()
+}
+
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *ThrowStatement) End() token.Position {
+	return ts.Value.End()
+}
+
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
 }
@@ -0,0 +1,169 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is called for each node found by Walk. If the
+// visitor w it returns is not nil, Walk visits each of the children of node
+// with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a tree in depth-first order: it starts by calling
+// v.Visit(node), and then walks the children of node in source order.
+// Optional children that are absent, such as a missing else branch, are
+// skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	// Statements
+	case *LetStatement:
+		if n.Pattern != nil {
+			Walk(v, n.Pattern)
+		} else {
+			Walk(v, n.Name)
+		}
+		Walk(v, n.Value)
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *ImportStatement:
+		Walk(v, n.Path)
+		Walk(v, n.Alias)
+	case *ExportStatement:
+		Walk(v, n.Statement)
+	case *ThrowStatement:
+		Walk(v, n.Value)
+
+	// Expressions
+	case *Identifier, *IntegerLiteral, *BooleanLiteral, *StringLiteral:
+		// no children
+	case *PrefixExpression:
+		Walk(v, n.Right)
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *IfExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *FunctionLiteral:
+		walkPatterns(v, n.Parameters)
+		Walk(v, n.Body)
+	case *CallExpression:
+		Walk(v, n.Function)
+		walkExpressions(v, n.Arguments)
+		for _, arg := range n.NamedArguments {
+			Walk(v, arg)
+		}
+	case *NamedArgument:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			Walk(v, pair.Key)
+			Walk(v, pair.Value)
+		}
+	case *MatchExpression:
+		Walk(v, n.Subject)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+	case *MatchArm:
+		Walk(v, n.Pattern)
+		if n.Guard != nil {
+			Walk(v, n.Guard)
+		}
+		Walk(v, n.Body)
+	case *MemberExpression:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+	case *TryExpression:
+		Walk(v, n.Block)
+		if n.Parameter != nil {
+			Walk(v, n.Parameter)
+		}
+		if n.Catch != nil {
+			Walk(v, n.Catch)
+		}
+		if n.Finally != nil {
+			Walk(v, n.Finally)
+		}
+
+	// Patterns
+	case *WildcardPattern:
+		// no children
+	case *LiteralPattern:
+		Walk(v, n.Value)
+	case *IdentifierPattern:
+		Walk(v, n.Name)
+	case *RestPattern:
+		Walk(v, n.Name)
+	case *DefaultPattern:
+		Walk(v, n.Target)
+		Walk(v, n.Default)
+	case *ArrayPattern:
+		walkPatterns(v, n.Elements)
+	case *HashPattern:
+		for _, pair := range n.Pairs {
+			Walk(v, pair.Key)
+			Walk(v, pair.Value)
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, stmts []Statement) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, exps []Expression) {
+	for _, exp := range exps {
+		Walk(v, exp)
+	}
+}
+
+func walkPatterns(v Visitor, patterns []Pattern) {
+	for _, pattern := range patterns {
+		Walk(v, pattern)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a tree in depth-first order like Walk, calling f for
+// each node. If f returns true, Inspect goes on to the children of node,
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
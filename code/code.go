@@ -0,0 +1,304 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+	OpMinus
+	OpBang
+
+	OpJump
+	OpJumpNotTruthy
+	OpJumpIfDefined
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetFree
+	OpCurrentClosure
+	OpCaptureLocal
+	OpCaptureFree
+
+	OpArray
+	OpHash
+	OpIndex
+	OpMember
+
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+
+	OpDestructureArray
+	OpDestructureHash
+	OpMatchArray
+	OpMatchHash
+	OpHasKey
+	OpElement
+	OpRestOf
+
+	OpTry
+	OpEndTry
+	OpCatch
+	OpThrow
+	OpRethrow
+
+	OpImport
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpNull:     {"OpNull", []int{}},
+
+	OpAdd:         {"OpAdd", []int{}},
+	OpSub:         {"OpSub", []int{}},
+	OpMul:         {"OpMul", []int{}},
+	OpDiv:         {"OpDiv", []int{}},
+	OpEqual:       {"OpEqual", []int{}},
+	OpNotEqual:    {"OpNotEqual", []int{}},
+	OpGreaterThan: {"OpGreaterThan", []int{}},
+	OpLessThan:    {"OpLessThan", []int{}},
+	OpMinus:       {"OpMinus", []int{}},
+	OpBang:        {"OpBang", []int{}},
+
+	// OpJumpIfDefined jumps when the local slot of its first operand holds
+	// a value, which skips the default of a parameter given by the caller.
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJumpIfDefined: {"OpJumpIfDefined", []int{1, 2}},
+
+	OpGetGlobal:      {"OpGetGlobal", []int{2}},
+	OpSetGlobal:      {"OpSetGlobal", []int{2}},
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+	// OpCaptureLocal and OpCaptureFree push a variable rather than its
+	// value, for OpClosure to capture. A captured local is moved to a cell,
+	// which OpGetLocal and OpSetLocal then read and write through.
+	OpCaptureLocal: {"OpCaptureLocal", []int{1}},
+	OpCaptureFree:  {"OpCaptureFree", []int{1}},
+
+	// OpHash takes the number of keys and values on the stack, and OpMember
+	// the constant holding the name of the member.
+	OpArray:  {"OpArray", []int{2}},
+	OpHash:   {"OpHash", []int{2}},
+	OpIndex:  {"OpIndex", []int{}},
+	OpMember: {"OpMember", []int{2}},
+
+	// OpCall takes the number of positional arguments and the number of
+	// named arguments, which are pushed as name and value pairs after them.
+	OpCall:        {"OpCall", []int{1, 1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}},
+
+	// The destructuring instructions raise an error when the value does not
+	// fit the pattern, and push its parts so that the first one is on top.
+	// Their operands are the number of elements and whether a rest element
+	// follows them.
+	OpDestructureArray: {"OpDestructureArray", []int{2, 1}},
+	OpDestructureHash:  {"OpDestructureHash", []int{2}},
+	// The match instructions push whether the value fits the pattern
+	// instead, and leave the extraction of its parts to OpElement, OpRestOf
+	// and OpIndex.
+	OpMatchArray: {"OpMatchArray", []int{2, 1}},
+	OpMatchHash:  {"OpMatchHash", []int{}},
+	OpHasKey:     {"OpHasKey", []int{}},
+	OpElement:    {"OpElement", []int{2}},
+	OpRestOf:     {"OpRestOf", []int{2}},
+
+	// OpTry installs a handler that jumps to its operand with the raised
+	// error pushed when an error is raised before the matching OpEndTry.
+	// OpCatch turns that error into the value bound by a catch clause, and
+	// OpRethrow raises it again.
+	OpTry:     {"OpTry", []int{2}},
+	OpEndTry:  {"OpEndTry", []int{}},
+	OpCatch:   {"OpCatch", []int{}},
+	OpThrow:   {"OpThrow", []int{}},
+	OpRethrow: {"OpRethrow", []int{}},
+
+	OpImport: {"OpImport", []int{2}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// CheckOperands returns an error if an operand does not fit in the width
+// op gives it.
+func CheckOperands(op Opcode, operands ...int) error {
+	def, ok := definitions[op]
+	if !ok {
+		return fmt.Errorf("opcode %d undefined", op)
+	}
+
+	for i, o := range operands {
+		if max := 1<<(8*def.OperandWidths[i]) - 1; o < 0 || o > max {
+			return fmt.Errorf("operand %d of %s out of range: %d is not between 0 and %d",
+				i, def.Name, o, max)
+		}
+	}
+	return nil
+}
+
+// Make encodes an instruction. It panics if an operand does not fit in its
+// width, so operands that are not known to fit must go through
+// CheckOperands first.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	if err := CheckOperands(op, operands...); err != nil {
+		panic("code.Make: " + err.Error())
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			return out.String()
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n",
+			len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+// SourcePosition records that the instructions from Offset onwards were
+// compiled from the source at Pos.
+type SourcePosition struct {
+	Offset int
+	Pos    token.Position
+}
+
+// SourceMap is sorted by Offset.
+type SourceMap []SourcePosition
+
+// Lookup returns the position of the source the instruction at offset was
+// compiled from.
+func (m SourceMap) Lookup(offset int) token.Position {
+	pos := token.Position{}
+	for _, entry := range m {
+		if entry.Offset > offset {
+			break
+		}
+		pos = entry.Pos
+	}
+	return pos
+}
@@ -0,0 +1,993 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+type CompilationScope struct {
+	instructions        code.Instructions
+	sourceMap           code.SourceMap
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+	tries               []*tryContext
+}
+
+// tryContext tracks a try expression being compiled, so that a return
+// inside it can remove its handler and run its finally block first.
+type tryContext struct {
+	finally *ast.BlockStatement
+}
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	pos       token.Position
+	tempCount int
+	exports   []string
+
+	// err is the first instruction that could not be encoded, which
+	// Compile returns once the node being compiled is done.
+	err error
+}
+
+type Bytecode struct {
+	Instructions code.Instructions
+	SourceMap    code.SourceMap
+	Constants    []object.Object
+	// Globals holds the names of the global slots, by index.
+	Globals []string
+	// Exports maps the names exported by the program to their global slots.
+	Exports map[string]int
+}
+
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+// NewWithState returns a compiler that keeps the globals and constants of an
+// earlier compilation, as the REPL does between lines.
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	return &Compiler{
+		constants:   constants,
+		symbolTable: s,
+		scopes:      []CompilationScope{{}},
+	}
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	prev := c.pos
+	if pos, ok := nodePos(node); ok {
+		c.pos = pos
+	}
+	defer func() { c.pos = prev }()
+
+	if err := c.compile(node); err != nil {
+		return err
+	}
+	return c.err
+}
+
+func (c *Compiler) compile(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if node.Expression == nil {
+			return nil
+		}
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		return c.compileLetStatement(node)
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if err := c.leaveTries(); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.ImportStatement:
+		c.emit(code.OpImport, c.addConstant(&object.String{Value: node.Path.Value}))
+		c.setSymbol(c.symbolTable.Define(node.Alias.Value))
+	case *ast.ExportStatement:
+		if err := c.Compile(node.Statement); err != nil {
+			return err
+		}
+		if c.scopeIndex == 0 {
+			c.exports = append(c.exports, letStatementNames(node.Statement)...)
+		}
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.BooleanLiteral:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		default:
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+	case *ast.InfixExpression:
+		return c.compileInfixExpression(node)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			// The name may be defined by a later global let, so it is
+			// left to the VM to report it when the slot is still unset.
+			symbol = c.symbolTable.Global().Define(node.Value)
+		}
+		c.loadSymbol(symbol)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node, "")
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, arg := range node.Arguments {
+			if err := c.Compile(arg); err != nil {
+				return err
+			}
+		}
+		for _, arg := range node.NamedArguments {
+			c.emit(code.OpConstant, c.addConstant(&object.String{Value: arg.Name.Value}))
+			if err := c.Compile(arg.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments), len(node.NamedArguments))
+	case *ast.MemberExpression:
+		if err := c.Compile(node.Object); err != nil {
+			return err
+		}
+		c.emit(code.OpMember, c.addConstant(&object.String{Value: node.Property.Value}))
+	case *ast.MatchExpression:
+		return c.compileMatchExpression(node)
+	case *ast.TryExpression:
+		return c.compileTryExpression(node)
+	default:
+		return fmt.Errorf("cannot compile %T", node)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileLetStatement(stmt *ast.LetStatement) error {
+	if stmt.Pattern != nil {
+		if err := c.Compile(stmt.Value); err != nil {
+			return err
+		}
+		return c.compileBinding(stmt.Pattern)
+	}
+
+	fn, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		if err := c.Compile(stmt.Value); err != nil {
+			return err
+		}
+		c.setSymbol(c.symbolTable.Define(stmt.Name.Value))
+		return nil
+	}
+
+	// A local function refers to itself through OpCurrentClosure, since its
+	// own slot is not set yet when its free variables are captured.
+	symbol := c.symbolTable.Define(stmt.Name.Value)
+	name := ""
+	if symbol.Scope == LocalScope {
+		name = stmt.Name.Value
+	}
+
+	if err := c.compileFunctionLiteral(fn, name); err != nil {
+		return err
+	}
+
+	c.setSymbol(symbol)
+	return nil
+}
+
+func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	switch node.Operator {
+	case "+":
+		c.emit(code.OpAdd)
+	case "-":
+		c.emit(code.OpSub)
+	case "*":
+		c.emit(code.OpMul)
+	case "/":
+		c.emit(code.OpDiv)
+	case ">":
+		c.emit(code.OpGreaterThan)
+	case "<":
+		c.emit(code.OpLessThan)
+	case "==":
+		c.emit(code.OpEqual)
+	case "!=":
+		c.emit(code.OpNotEqual)
+	default:
+		return fmt.Errorf("unknown operator %s", node.Operator)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlockValue(node.Alternative); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileBlockValue compiles a block that leaves the value of its last
+// expression statement on the stack, or null if there is none.
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileFunctionLiteral(fn *ast.FunctionLiteral, name string) error {
+	c.enterScope()
+	c.symbolTable.later = boundNames(fn.Body)
+
+	if name != "" {
+		c.symbolTable.DefineFunctionName(name)
+	}
+
+	fixed, rest := ast.SplitRest(fn.Parameters)
+
+	targets := make([]ast.Pattern, len(fixed))
+	names := make([]string, len(fixed))
+	required := 0
+	for i, param := range fixed {
+		targets[i] = param
+		if pattern, ok := param.(*ast.DefaultPattern); ok {
+			targets[i] = pattern.Target
+		} else {
+			required++
+		}
+
+		if ident, ok := targets[i].(*ast.IdentifierPattern); ok {
+			names[i] = ident.Name.Value
+			c.symbolTable.DefineParameter(names[i])
+		} else {
+			c.symbolTable.DefineParameter(fmt.Sprintf("$param%d", i))
+		}
+	}
+	if rest != nil {
+		c.symbolTable.DefineParameter(rest.Name.Value)
+	}
+
+	// The VM leaves the slot of a parameter that was not given unset, and
+	// the prologue fills it with the default and destructures the pattern
+	// parameters, in order, so that a default can refer to earlier ones.
+	for i, param := range fixed {
+		if pattern, ok := param.(*ast.DefaultPattern); ok {
+			jumpPos := c.emit(code.OpJumpIfDefined, i, 9999)
+			if err := c.Compile(pattern.Default); err != nil {
+				return err
+			}
+			c.emit(code.OpSetLocal, i)
+			c.changeOperand(jumpPos, len(c.currentInstructions()))
+		}
+
+		if names[i] == "" {
+			c.emit(code.OpGetLocal, i)
+			if err := c.compileBinding(targets[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Compile(fn.Body); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.NumDefinitions()
+	localNames := append([]string{}, c.symbolTable.Names()...)
+	instructions, sourceMap := c.leaveScope()
+
+	for _, s := range freeSymbols {
+		c.captureSymbol(s)
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Name:           name,
+		Instructions:   instructions,
+		SourceMap:      sourceMap,
+		NumLocals:      numLocals,
+		NumParameters:  len(fixed),
+		NumRequired:    required,
+		Variadic:       rest != nil,
+		ParameterNames: names,
+		LocalNames:     localNames,
+	}
+
+	c.emit(code.OpClosure, c.addConstant(compiledFn), len(freeSymbols))
+	return nil
+}
+
+// compileBinding destructures the value on top of the stack through pattern,
+// defining the names it binds in the current symbol table.
+func (c *Compiler) compileBinding(pattern ast.Pattern) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		c.emit(code.OpPop)
+	case *ast.IdentifierPattern:
+		c.setSymbol(c.symbolTable.Define(pattern.Name.Value))
+	case *ast.ArrayPattern:
+		fixed, rest := ast.SplitRest(pattern.Elements)
+
+		hasRest := 0
+		if rest != nil {
+			hasRest = 1
+		}
+		c.emit(code.OpDestructureArray, len(fixed), hasRest)
+
+		for _, element := range fixed {
+			if err := c.compileBinding(element); err != nil {
+				return err
+			}
+		}
+		if rest != nil {
+			c.setSymbol(c.symbolTable.Define(rest.Name.Value))
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpDestructureHash, len(pattern.Pairs))
+
+		for _, pair := range pattern.Pairs {
+			if err := c.compileBinding(pair.Value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot bind to pattern: %s", pattern.String())
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileMatchExpression(node *ast.MatchExpression) error {
+	if err := c.Compile(node.Subject); err != nil {
+		return err
+	}
+
+	subject := c.defineTemp("$match")
+	c.setSymbol(subject)
+	load := func() { c.loadSymbol(subject) }
+
+	endJumps := []int{}
+	for _, arm := range node.Arms {
+		c.enterBlock()
+		c.symbolTable.later = boundNames(arm)
+
+		failJumps := []int{}
+		if err := c.compileMatch(arm.Pattern, load, &failJumps); err != nil {
+			return err
+		}
+
+		if arm.Guard != nil {
+			if err := c.Compile(arm.Guard); err != nil {
+				return err
+			}
+			failJumps = append(failJumps, c.emit(code.OpJumpNotTruthy, 9999))
+		}
+
+		if err := c.Compile(arm.Body); err != nil {
+			return err
+		}
+		endJumps = append(endJumps, c.emit(code.OpJump, 9999))
+
+		c.leaveBlock()
+
+		for _, pos := range failJumps {
+			c.changeOperand(pos, len(c.currentInstructions()))
+		}
+	}
+
+	c.emit(code.OpNull)
+
+	for _, pos := range endJumps {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+
+	return nil
+}
+
+// compileMatch tests the value pushed by load against pattern, binding the
+// names it captures. The positions of the jumps taken when the value does
+// not match are appended to failJumps.
+func (c *Compiler) compileMatch(pattern ast.Pattern, load func(), failJumps *[]int) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+	case *ast.IdentifierPattern:
+		load()
+		c.setSymbol(c.symbolTable.Define(pattern.Name.Value))
+	case *ast.LiteralPattern:
+		load()
+		if err := c.Compile(pattern.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpEqual)
+		*failJumps = append(*failJumps, c.emit(code.OpJumpNotTruthy, 9999))
+	case *ast.ArrayPattern:
+		fixed, rest := ast.SplitRest(pattern.Elements)
+
+		hasRest := 0
+		if rest != nil {
+			hasRest = 1
+		}
+		load()
+		c.emit(code.OpMatchArray, len(fixed), hasRest)
+		*failJumps = append(*failJumps, c.emit(code.OpJumpNotTruthy, 9999))
+
+		for i, element := range fixed {
+			index := i
+			loadElement := func() {
+				load()
+				c.emit(code.OpElement, index)
+			}
+			if err := c.compileMatch(element, loadElement, failJumps); err != nil {
+				return err
+			}
+		}
+
+		if rest != nil {
+			load()
+			c.emit(code.OpRestOf, len(fixed))
+			c.setSymbol(c.symbolTable.Define(rest.Name.Value))
+		}
+	case *ast.HashPattern:
+		load()
+		c.emit(code.OpMatchHash)
+		*failJumps = append(*failJumps, c.emit(code.OpJumpNotTruthy, 9999))
+
+		for _, pair := range pattern.Pairs {
+			key := pair.Key
+
+			load()
+			if err := c.Compile(key); err != nil {
+				return err
+			}
+			c.emit(code.OpHasKey)
+			*failJumps = append(*failJumps, c.emit(code.OpJumpNotTruthy, 9999))
+
+			var keyErr error
+			loadValue := func() {
+				load()
+				if err := c.Compile(key); err != nil {
+					keyErr = err
+				}
+				c.emit(code.OpIndex)
+			}
+			if err := c.compileMatch(pair.Value, loadValue, failJumps); err != nil {
+				return err
+			}
+			if keyErr != nil {
+				return keyErr
+			}
+		}
+	default:
+		return fmt.Errorf("unknown pattern: %s", pattern.String())
+	}
+
+	return nil
+}
+
+// compileTryExpression lays out a try expression as
+//
+//	OpTry catch; block; OpEndTry; OpJump done
+//	catch: OpCatch; [OpTry rethrow]; bind; catch block; [OpEndTry]
+//	done: [finally; OpJump end; rethrow: finally; OpRethrow; end:]
+//
+// where the bracketed parts are only emitted when there is a finally block.
+// A try without a catch clause jumps straight to rethrow on error.
+func (c *Compiler) compileTryExpression(node *ast.TryExpression) error {
+	c.enterTry(node.Finally)
+	tryPos := c.emit(code.OpTry, 9999)
+	if err := c.compileBlockValue(node.Block); err != nil {
+		return err
+	}
+	c.emit(code.OpEndTry)
+	c.leaveTry()
+
+	if node.Catch == nil {
+		if err := c.compileFinally(node.Finally, tryPos); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	doneJump := c.emit(code.OpJump, 9999)
+	c.changeOperand(tryPos, len(c.currentInstructions()))
+
+	// The caught error is kept in a slot rather than on the stack, so that
+	// the stack is the same as around the expression if the catch block
+	// raises in turn.
+	caught := c.defineTemp("$error")
+	c.emit(code.OpCatch)
+	c.setSymbol(caught)
+
+	rethrowPos := -1
+	if node.Finally != nil {
+		c.enterTry(node.Finally)
+		rethrowPos = c.emit(code.OpTry, 9999)
+	}
+
+	c.enterBlock()
+	c.symbolTable.later = boundNames(node.Catch)
+	c.loadSymbol(caught)
+	if err := c.compileBinding(node.Parameter); err != nil {
+		return err
+	}
+	if err := c.compileBlockValue(node.Catch); err != nil {
+		return err
+	}
+	c.leaveBlock()
+
+	if node.Finally != nil {
+		c.emit(code.OpEndTry)
+		c.leaveTry()
+	}
+
+	c.changeOperand(doneJump, len(c.currentInstructions()))
+
+	if node.Finally == nil {
+		return nil
+	}
+	return c.compileFinally(node.Finally, rethrowPos)
+}
+
+// compileFinally emits the finally block once for the normal path and once
+// for the path taken when the handler at handlerPos catches an error, which
+// is raised again afterwards.
+func (c *Compiler) compileFinally(finally *ast.BlockStatement, handlerPos int) error {
+	if err := c.Compile(finally); err != nil {
+		return err
+	}
+	endJump := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(handlerPos, len(c.currentInstructions()))
+	if err := c.Compile(finally); err != nil {
+		return err
+	}
+	c.emit(code.OpRethrow)
+
+	c.changeOperand(endJump, len(c.currentInstructions()))
+	return nil
+}
+
+func (c *Compiler) enterTry(finally *ast.BlockStatement) {
+	scope := &c.scopes[c.scopeIndex]
+	scope.tries = append(scope.tries, &tryContext{finally: finally})
+}
+
+func (c *Compiler) leaveTry() {
+	scope := &c.scopes[c.scopeIndex]
+	scope.tries = scope.tries[:len(scope.tries)-1]
+}
+
+// leaveTries removes the handlers of the try expressions a return leaves
+// and runs their finally blocks, innermost first.
+func (c *Compiler) leaveTries() error {
+	scope := &c.scopes[c.scopeIndex]
+	tries := scope.tries
+	defer func() { c.scopes[c.scopeIndex].tries = tries }()
+
+	for i := len(tries) - 1; i >= 0; i-- {
+		c.emit(code.OpEndTry)
+		if tries[i].finally == nil {
+			continue
+		}
+
+		c.scopes[c.scopeIndex].tries = tries[:i]
+		if err := c.Compile(tries[i].finally); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileNested compiles an expression that is part of a larger construct
+// without letting it change the source position of what follows.
+func (c *Compiler) compileNested(node ast.Node) error {
+	return c.Compile(node)
+}
+
+func (c *Compiler) defineTemp(prefix string) Symbol {
+	name := fmt.Sprintf("%s%d", prefix, c.tempCount)
+	c.tempCount++
+	return c.symbolTable.Define(name)
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+// captureSymbol pushes the variable of s for a closure to capture, so that
+// the closure sees the values it is bound to after the closure is created.
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	default:
+		c.loadSymbol(s)
+	}
+}
+
+func (c *Compiler) setSymbol(s Symbol) {
+	if s.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, s.Index)
+	} else {
+		c.emit(code.OpSetLocal, s.Index)
+	}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	if !c.checkOperands(op, operands...) {
+		return len(c.currentInstructions())
+	}
+
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	scope := &c.scopes[c.scopeIndex]
+	posNewInstruction := len(scope.instructions)
+
+	if n := len(scope.sourceMap); n == 0 || scope.sourceMap[n-1].Pos != c.pos {
+		scope.sourceMap = append(scope.sourceMap, code.SourcePosition{
+			Offset: posNewInstruction,
+			Pos:    c.pos,
+		})
+	}
+
+	scope.instructions = append(scope.instructions, ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	scope := &c.scopes[c.scopeIndex]
+	last := scope.lastInstruction
+
+	scope.instructions = scope.instructions[:last.Position]
+	for n := len(scope.sourceMap); n > 0 && scope.sourceMap[n-1].Offset >= last.Position; n-- {
+		scope.sourceMap = scope.sourceMap[:n-1]
+	}
+	scope.lastInstruction = scope.previousInstruction
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	if c.err != nil {
+		return
+	}
+
+	op := code.Opcode(c.currentInstructions()[opPos])
+	def, _ := code.Lookup(byte(op))
+
+	// Only the last operand of an instruction is ever a jump target.
+	operands, _ := code.ReadOperands(def, c.currentInstructions()[opPos+1:])
+	operands[len(operands)-1] = operand
+
+	if c.checkOperands(op, operands...) {
+		c.replaceInstruction(opPos, code.Make(op, operands...))
+	}
+}
+
+// checkOperands reports whether the operands of an instruction fit in it,
+// recording the error if they do not: too many constants, globals, locals
+// or arguments, or a jump too far.
+func (c *Compiler) checkOperands(op code.Opcode, operands ...int) bool {
+	if c.err != nil {
+		return false
+	}
+	if err := code.CheckOperands(op, operands...); err != nil {
+		c.err = fmt.Errorf("%s: program too large: %s", c.pos, err)
+		return false
+	}
+	return true
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{})
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, code.SourceMap) {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope.instructions, scope.sourceMap
+}
+
+func (c *Compiler) enterBlock() {
+	c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveBlock() {
+	c.symbolTable = c.symbolTable.Outer
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	global := c.symbolTable.Global()
+
+	exports := map[string]int{}
+	for _, name := range c.exports {
+		if symbol, ok := global.Resolve(name); ok && symbol.Scope == GlobalScope {
+			exports[name] = symbol.Index
+		}
+	}
+
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		SourceMap:    c.scopes[c.scopeIndex].sourceMap,
+		Constants:    c.constants,
+		Globals:      global.Names(),
+		Exports:      exports,
+	}
+}
+
+// boundNames returns the names that the lets and imports in node bind in
+// the scope node is part of, leaving out those of the functions, match arms
+// and catch clauses in it, which have scopes of their own.
+func boundNames(node ast.Node) map[string]bool {
+	names := map[string]bool{}
+	catches := map[*ast.BlockStatement]bool{}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return n == node
+		case *ast.MatchArm:
+			return n == node
+		case *ast.TryExpression:
+			catches[n.Catch] = true
+		case *ast.BlockStatement:
+			return n == node || !catches[n]
+		case *ast.LetStatement:
+			for _, name := range letStatementNames(n) {
+				names[name] = true
+			}
+		case *ast.ImportStatement:
+			names[n.Alias.Value] = true
+		}
+		return true
+	})
+
+	return names
+}
+
+func letStatementNames(stmt *ast.LetStatement) []string {
+	if stmt.Pattern == nil {
+		return []string{stmt.Name.Value}
+	}
+	return patternNames(stmt.Pattern)
+}
+
+func patternNames(pattern ast.Pattern) []string {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		return []string{pattern.Name.Value}
+	case *ast.RestPattern:
+		return []string{pattern.Name.Value}
+	case *ast.ArrayPattern:
+		names := []string{}
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+		return names
+	case *ast.HashPattern:
+		names := []string{}
+		for _, pair := range pattern.Pairs {
+			names = append(names, patternNames(pair.Value)...)
+		}
+		return names
+	default:
+		return []string{}
+	}
+}
+
+// nodePos returns the position recorded in the source map for the
+// instructions compiled from node.
+func nodePos(node ast.Node) (token.Position, bool) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return node.Token.Pos, true
+	case *ast.PrefixExpression:
+		return node.Token.Pos, true
+	case *ast.InfixExpression:
+		return node.Token.Pos, true
+	case *ast.IfExpression:
+		return node.Token.Pos, true
+	case *ast.CallExpression:
+		return node.Token.Pos, true
+	case *ast.MemberExpression:
+		return node.Token.Pos, true
+	case *ast.HashLiteral:
+		return node.Token.Pos, true
+	case *ast.MatchExpression:
+		return node.Token.Pos, true
+	case *ast.TryExpression:
+		return node.Token.Pos, true
+	case *ast.ExpressionStatement:
+		return node.Token.Pos, true
+	case *ast.LetStatement:
+		return node.Token.Pos, true
+	case *ast.ReturnStatement:
+		return node.Token.Pos, true
+	case *ast.ImportStatement:
+		return node.Token.Pos, true
+	case *ast.ThrowStatement:
+		return node.Token.Pos, true
+	default:
+		return token.Position{}, false
+	}
+}
@@ -0,0 +1,648 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []interface{}
+	expectedInstructions []code.Instructions
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 < 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpLessThan),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "-1; !true",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+				code.Make(code.OpTrue),
+				code.Make(code.OpBang),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "if (true) { 10 }; 3333;",
+			expectedConstants: []interface{}{10, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "if (true) { 10 } else { 20 }",
+			expectedConstants: []interface{}{10, 20},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 13),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let one = 1; let two = one; two;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let [a, ...b] = [1]; b",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpDestructureArray, 1, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCollections(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `{"a": 1}.a`,
+			expectedConstants: []interface{}{"a", 1, "a"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpHash, 2),
+				code.Make(code.OpMember, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "fn() { return 5 + 10 }",
+			expectedConstants: []interface{}{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn(a, b = a) { b }(1, b: 2)",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpJumpIfDefined, 1, 8),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				"b",
+				2,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpCall, 1, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "fn(a) { fn(b) { a + b } }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 0, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { let f = fn(x) { f(x) }; f }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpCurrentClosure),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 1, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 0, 0),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "match (1) { 1 => true, x => x }",
+			expectedConstants: []interface{}{1, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpEqual),
+				code.Make(code.OpJumpNotTruthy, 20),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJump, 33),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpJump, 33),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestTryExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "try { 1 } catch (e) { e }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTry, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpEndTry),
+				code.Make(code.OpJump, 23),
+				code.Make(code.OpCatch),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "try { 1 } finally { 2 }",
+			expectedConstants: []interface{}{1, 2, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTry, 14),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpEndTry),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpJump, 19),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+				code.Make(code.OpRethrow),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { try { return 1 } finally { 2 } }",
+			expectedConstants: []interface{}{
+				1,
+				2,
+				2,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpTry, 21),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpEndTry),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpPop),
+					code.Make(code.OpReturnValue),
+					code.Make(code.OpNull),
+					code.Make(code.OpEndTry),
+					code.Make(code.OpConstant, 2),
+					code.Make(code.OpPop),
+					code.Make(code.OpJump, 26),
+					code.Make(code.OpConstant, 3),
+					code.Make(code.OpPop),
+					code.Make(code.OpRethrow),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 4, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSourceMap(t *testing.T) {
+	program := parse("let a = 1;\nlet b = a + 2;")
+
+	compiler := New()
+	assert.NoError(t, compiler.Compile(program))
+
+	bytecode := compiler.Bytecode()
+
+	// OpAdd is the sixth instruction, after two OpConstant, an OpSetGlobal
+	// and an OpGetGlobal and another OpConstant.
+	pos := bytecode.SourceMap.Lookup(3 + 3 + 3 + 3)
+	assert.Equal(t, 2, pos.Line)
+	assert.Equal(t, 11, pos.Column)
+}
+
+func TestResolveScopes(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	local := NewEnclosedSymbolTable(global)
+	local.Define("b")
+
+	block := NewBlockSymbolTable(local)
+	block.Define("c")
+
+	nested := NewEnclosedSymbolTable(block)
+	nested.Define("d")
+
+	tests := []struct {
+		table    *SymbolTable
+		name     string
+		expected Symbol
+	}{
+		{block, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{block, "b", Symbol{Name: "b", Scope: LocalScope, Index: 0}},
+		{block, "c", Symbol{Name: "c", Scope: LocalScope, Index: 1}},
+		{nested, "d", Symbol{Name: "d", Scope: LocalScope, Index: 0}},
+		{nested, "c", Symbol{Name: "c", Scope: FreeScope, Index: 0}},
+		{nested, "b", Symbol{Name: "b", Scope: FreeScope, Index: 1}},
+	}
+
+	for _, tt := range tests {
+		symbol, ok := tt.table.Resolve(tt.name)
+		assert.True(t, ok, tt.name)
+		assert.Equal(t, tt.expected, symbol)
+	}
+
+	_, ok := local.Resolve("c")
+	assert.False(t, ok)
+	assert.Equal(t, 2, local.NumDefinitions())
+	assert.Equal(t, []Symbol{
+		{Name: "c", Scope: LocalScope, Index: 1},
+		{Name: "b", Scope: LocalScope, Index: 0},
+	}, nested.FreeSymbols)
+}
+
+func TestResolveLaterNames(t *testing.T) {
+	local := NewEnclosedSymbolTable(NewSymbolTable())
+	local.later = map[string]bool{"h": true}
+	nested := NewEnclosedSymbolTable(local)
+
+	_, ok := local.Resolve("h")
+	assert.False(t, ok, "a name is not visible before its let in its own scope")
+
+	symbol, ok := nested.Resolve("h")
+	assert.True(t, ok)
+	assert.Equal(t, Symbol{Name: "h", Scope: FreeScope, Index: 0}, symbol)
+	assert.Equal(t, []Symbol{{Name: "h", Scope: LocalScope, Index: 0}}, nested.FreeSymbols)
+
+	_, ok = local.Resolve("h")
+	assert.False(t, ok)
+	assert.Equal(t, Symbol{Name: "h", Scope: LocalScope, Index: 0}, local.Define("h"))
+	assert.Equal(t, 1, local.NumDefinitions())
+}
+
+func TestOperandLimits(t *testing.T) {
+	let := func(i int) string { return "let " + name(i) + " = true; " }
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"fn() { " + repeat(256, let) + "}", ""},
+		{"fn() { " + repeat(257, let) + "}", "operand 0 of OpSetLocal out of range: 256 is not between 0 and 255"},
+		{"fn() {}(" + repeat(255, strconv.Itoa, ", ") + ")", ""},
+		{"fn() {}(" + repeat(256, strconv.Itoa, ", ") + ")", "operand 0 of OpCall out of range: 256 is not between 0 and 255"},
+		{repeat(65536, strconv.Itoa, "; "), ""},
+		{repeat(65537, strconv.Itoa, "; "), "operand 0 of OpConstant out of range: 65536 is not between 0 and 65535"},
+		{repeat(65536, let), ""},
+		{repeat(65537, let), "operand 0 of OpSetGlobal out of range: 65536 is not between 0 and 65535"},
+		{"if (true) { " + repeat(40000, func(int) string { return "true" }, "; ") + " }",
+			"operand 0 of OpJumpNotTruthy out of range"},
+	}
+
+	for _, tt := range tests {
+		err := New().Compile(parse(tt.input))
+		if tt.expectedMessage == "" {
+			assert.NoError(t, err)
+		} else if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), tt.expectedMessage)
+		}
+	}
+}
+
+// repeat joins the strings made from 0 to n-1 by element.
+func repeat(n int, element func(int) string, separator ...string) string {
+	elements := make([]string, n)
+	for i := range elements {
+		elements[i] = element(i)
+	}
+	return strings.Join(elements, strings.Join(separator, ""))
+}
+
+// name returns a distinct identifier for each i, since identifiers cannot
+// contain digits. No keyword starts with x.
+func name(i int) string {
+	letters := ""
+	for ; i >= 0; i = i/26 - 1 {
+		letters = string(rune('a'+i%26)) + letters
+	}
+	return "x" + letters
+}
+
+func TestBytecodeRoundTrip(t *testing.T) {
+	input := `
+	let f = fn(a, [b, c], d = 1, ...rest) { a + b + c + d + len(rest) };
+	export let x = f(1, [2, 3], 4, "five");
+	`
+
+	compiler := New()
+	assert.NoError(t, compiler.Compile(parse(input)))
+	bytecode := compiler.Bytecode()
+
+	data, err := bytecode.MarshalBinary()
+	assert.NoError(t, err)
+	assert.True(t, IsBytecode(data))
+
+	loaded := &Bytecode{}
+	assert.NoError(t, loaded.UnmarshalBinary(data))
+	assert.Equal(t, bytecode, loaded)
+}
+
+func TestBytecodeValidation(t *testing.T) {
+	compiler := New()
+	assert.NoError(t, compiler.Compile(parse("let a = 1; a")))
+	bytecode := compiler.Bytecode()
+
+	data, err := bytecode.MarshalBinary()
+	assert.NoError(t, err)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(Magic)+3] ^= 0xff
+
+	wrongVersion := append([]byte{}, data...)
+	wrongVersion[len(Magic)] = BytecodeVersion + 1
+
+	// appended returns the bytecode with an instruction added to main.
+	appended := func(ins []byte) []byte {
+		changed := *bytecode
+		changed.Instructions = append(append(code.Instructions{}, bytecode.Instructions...), ins...)
+		data, err := changed.MarshalBinary()
+		assert.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		data            []byte
+		expectedMessage string
+	}{
+		{[]byte("let a = 1;"), "not a compiled monkey file"},
+		{data[:len(data)-1], "bytecode checksum mismatch"},
+		{corrupted, "bytecode checksum mismatch"},
+		{withChecksum(wrongVersion), "unsupported bytecode version 4, want 3"},
+		{appended(code.Make(code.OpConstant, 9)), "OpConstant at 0010 refers to missing constant 9"},
+		{appended(code.Make(code.OpMember, 0)), "OpMember at 0010 refers to constant 0, which is not a string"},
+		{appended(code.Make(code.OpClosure, 0, 0)), "OpClosure at 0010 refers to constant 0, which is not a function"},
+		{appended(code.Make(code.OpGetLocal, 0)), "OpGetLocal at 0010 refers to missing local 0"},
+		{appended(code.Make(code.OpJump, 1)), "OpJump at 0010 jumps to 0001, which is not the start of an instruction"},
+		{appended(code.Make(code.OpTry, 99)), "OpTry at 0010 jumps to 0099, which is not the start of an instruction"},
+		{appended(code.Make(code.OpHash, 3)), "OpHash at 0010 takes 3 values, which do not make pairs"},
+		{appended(code.Make(code.OpPop)), "stack underflow at 0010: OpPop needs 1, stack has 0"},
+		{appended(code.Make(code.OpCall, 1, 0)), "stack underflow at 0010: OpCall needs 2, stack has 0"},
+		{
+			appended(concatInstructions([]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 15),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			})),
+			"stack height at 0015 is 0 on one path and 1 on another",
+		},
+	}
+
+	for _, tt := range tests {
+		err := (&Bytecode{}).UnmarshalBinary(tt.data)
+		if assert.Error(t, err) {
+			assert.Equal(t, tt.expectedMessage, err.Error())
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	compiler := New()
+	assert.NoError(t, compiler.Compile(parse("let add = fn(a, b) {\n  a + b\n};\nadd(1, 2)")))
+
+	expected := `main:
+  0000 1:1    OpClosure 0 0                ; 0 free
+  0004        OpSetGlobal 0                ; add
+  0007 4:1    OpGetGlobal 0                ; add
+  0010 4:4    OpConstant 1                 ; 1
+  0013        OpConstant 2                 ; 2
+  0016        OpCall 2 0                   ; 2 positional, 0 named
+  0019 4:1    OpPop
+
+constant 0: fn <anonymous>(a, b) locals=2
+  0000 2:3    OpGetLocal 0                 ; a
+  0002 2:7    OpGetLocal 1                 ; b
+  0004 2:5    OpAdd
+  0005 2:3    OpReturnValue
+`
+
+	assert.Equal(t, expected, Disassemble(compiler.Bytecode()))
+}
+
+func withChecksum(data []byte) []byte {
+	body := data[:len(data)-4]
+	out := append([]byte{}, body...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(body))
+}
+
+func parse(input string) *ast.Program {
+	l := lexer.NewLexer(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
+
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+
+		compiler := New()
+		assert.NoError(t, compiler.Compile(program), tt.input)
+
+		bytecode := compiler.Bytecode()
+		testInstructions(t, tt.input, tt.expectedInstructions, bytecode.Instructions)
+		testConstants(t, tt.input, tt.expectedConstants, bytecode.Constants)
+	}
+}
+
+func concatInstructions(s []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range s {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+func testInstructions(
+	t *testing.T,
+	input string,
+	expected []code.Instructions,
+	actual code.Instructions,
+) {
+	t.Helper()
+	assert.Equal(t, concatInstructions(expected).String(), actual.String(), input)
+}
+
+func testConstants(
+	t *testing.T,
+	input string,
+	expected []interface{},
+	actual []object.Object,
+) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected), input) {
+		return
+	}
+
+	for i, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[i].(*object.Integer)
+			if assert.True(t, ok, input) {
+				assert.Equal(t, int64(constant), integer.Value, input)
+			}
+		case string:
+			str, ok := actual[i].(*object.String)
+			if assert.True(t, ok, input) {
+				assert.Equal(t, constant, str.Value, input)
+			}
+		case []code.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if assert.True(t, ok, input) {
+				testInstructions(t, input, constant, fn.Instructions)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Disassemble lists the instructions of the program and of every compiled
+// function in its constant pool. Each instruction is shown with its offset,
+// the source position it was compiled from, and the constants, names and
+// jump targets its operands refer to.
+func Disassemble(b *Bytecode) string {
+	var out bytes.Buffer
+
+	out.WriteString("main:\n")
+	b.disassemble(&out, b.Instructions, b.SourceMap, nil)
+
+	for i, constant := range b.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			continue
+		}
+
+		name := fn.Name
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(&out, "\nconstant %d: fn %s(%s) locals=%d\n",
+			i, name, parameterList(fn), fn.NumLocals)
+		b.disassemble(&out, fn.Instructions, fn.SourceMap, fn.LocalNames)
+	}
+
+	if len(b.Exports) != 0 {
+		out.WriteString("\nexports:\n")
+		for _, name := range sortedKeys(b.Exports) {
+			fmt.Fprintf(&out, "  %s = global %d\n", name, b.Exports[name])
+		}
+	}
+
+	return out.String()
+}
+
+func (b *Bytecode) disassemble(
+	out *bytes.Buffer,
+	ins code.Instructions,
+	sourceMap code.SourceMap,
+	localNames []string,
+) {
+	lastPos := token.Position{}
+
+	for i := 0; i < len(ins); {
+		def, err := code.Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(out, "  %04d ERROR: %s\n", i, err)
+			return
+		}
+
+		operands, read := code.ReadOperands(def, ins[i+1:])
+
+		pos := ""
+		if p := sourceMap.Lookup(i); p != lastPos {
+			pos = p.String()
+			lastPos = p
+		}
+
+		line := fmt.Sprintf("%04d %-6s %s", i, pos, def.Name)
+		for _, operand := range operands {
+			line += fmt.Sprintf(" %d", operand)
+		}
+
+		if comment := b.describe(code.Opcode(ins[i]), operands, localNames); comment != "" {
+			line = fmt.Sprintf("%-40s ; %s", line, comment)
+		}
+		fmt.Fprintf(out, "  %s\n", line)
+
+		i += 1 + read
+	}
+}
+
+// describe explains the operands of an instruction.
+func (b *Bytecode) describe(op code.Opcode, operands []int, localNames []string) string {
+	switch op {
+	case code.OpConstant, code.OpMember, code.OpImport:
+		if operands[0] < len(b.Constants) {
+			return b.Constants[operands[0]].Inspect()
+		}
+	case code.OpClosure:
+		if fn, ok := b.Constants[operands[0]].(*object.CompiledFunction); ok && fn.Name != "" {
+			return fmt.Sprintf("fn %s, %d free", fn.Name, operands[1])
+		}
+		return fmt.Sprintf("%d free", operands[1])
+	case code.OpGetGlobal, code.OpSetGlobal:
+		if operands[0] < len(b.Globals) {
+			return b.Globals[operands[0]]
+		}
+	case code.OpGetLocal, code.OpSetLocal, code.OpCaptureLocal:
+		if operands[0] < len(localNames) {
+			return localNames[operands[0]]
+		}
+	case code.OpJumpIfDefined:
+		if operands[0] < len(localNames) {
+			return fmt.Sprintf("%s -> %04d", localNames[operands[0]], operands[1])
+		}
+	case code.OpJump, code.OpJumpNotTruthy, code.OpTry:
+		return fmt.Sprintf("-> %04d", operands[0])
+	case code.OpCall:
+		return fmt.Sprintf("%d positional, %d named", operands[0], operands[1])
+	}
+
+	return ""
+}
+
+func parameterList(fn *object.CompiledFunction) string {
+	var out bytes.Buffer
+
+	for i, name := range fn.ParameterNames {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		if name == "" {
+			name = "<pattern>"
+		}
+		out.WriteString(name)
+		if i >= fn.NumRequired {
+			out.WriteString("?")
+		}
+	}
+
+	if fn.Variadic {
+		if len(fn.ParameterNames) > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString("..." + fn.LocalNames[fn.NumParameters])
+	}
+
+	return out.String()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,540 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// The serialized bytecode starts with Magic and the format version, and
+// ends with the CRC-32 of everything before it. Any change to the layout
+// must bump BytecodeVersion.
+const (
+	Magic           = "MNKB"
+	BytecodeVersion = 3
+)
+
+const (
+	tagInteger byte = iota
+	tagString
+	tagCompiledFunction
+)
+
+var ErrNotBytecode = errors.New("not a compiled monkey file")
+
+// IsBytecode reports whether data starts like serialized bytecode.
+func IsBytecode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+func (b *Bytecode) MarshalBinary() ([]byte, error) {
+	w := &encoder{}
+	w.buf.WriteString(Magic)
+	w.uint(BytecodeVersion)
+
+	w.bytes(b.Instructions)
+	w.sourceMap(b.SourceMap)
+
+	w.uint(uint64(len(b.Constants)))
+	for _, constant := range b.Constants {
+		if err := w.constant(constant); err != nil {
+			return nil, err
+		}
+	}
+
+	w.strings(b.Globals)
+
+	w.uint(uint64(len(b.Exports)))
+	for _, name := range sortedKeys(b.Exports) {
+		w.string(name)
+		w.uint(uint64(b.Exports[name]))
+	}
+
+	checksum := crc32.ChecksumIEEE(w.buf.Bytes())
+	binary.Write(&w.buf, binary.BigEndian, checksum)
+
+	return w.buf.Bytes(), nil
+}
+
+// UnmarshalBinary loads bytecode written by MarshalBinary. It rejects data
+// of another version, data that was corrupted, and instructions that refer
+// to constants that do not exist.
+func (b *Bytecode) UnmarshalBinary(data []byte) error {
+	if !IsBytecode(data) {
+		return ErrNotBytecode
+	}
+	if len(data) < len(Magic)+4 {
+		return errors.New("bytecode is truncated")
+	}
+
+	body, sum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return errors.New("bytecode checksum mismatch")
+	}
+
+	r := &decoder{data: body, pos: len(Magic)}
+	if version := r.uint(); r.err == nil && version != BytecodeVersion {
+		return fmt.Errorf("unsupported bytecode version %d, want %d", version, BytecodeVersion)
+	}
+
+	loaded := &Bytecode{}
+	loaded.Instructions = r.bytes()
+	loaded.SourceMap = r.sourceMap()
+
+	numConstants := r.count()
+	for i := 0; i < numConstants && r.err == nil; i++ {
+		loaded.Constants = append(loaded.Constants, r.constant())
+	}
+
+	loaded.Globals = r.strings()
+
+	loaded.Exports = map[string]int{}
+	numExports := r.count()
+	for i := 0; i < numExports && r.err == nil; i++ {
+		name := r.string()
+		loaded.Exports[name] = int(r.uint())
+	}
+
+	if r.err == nil && r.pos != len(r.data) {
+		r.err = errors.New("trailing data after bytecode")
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	if err := loaded.validate(); err != nil {
+		return err
+	}
+
+	*b = *loaded
+	return nil
+}
+
+// validate checks that every instruction is defined and complete, and that
+// its operands are of the kind and in the range the VM expects: constants
+// of the right type, globals and locals that exist, and jumps to the start
+// of an instruction of the same function. It also checks that no
+// instruction takes more values from the stack than its function pushed.
+func (b *Bytecode) validate() error {
+	if err := b.check(b.Instructions, 0); err != nil {
+		return err
+	}
+	for i, constant := range b.Constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			if err := b.check(fn.Instructions, fn.NumLocals); err != nil {
+				return fmt.Errorf("constant %d: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// check validates the instructions of a function with numLocals locals.
+func (b *Bytecode) check(ins code.Instructions, numLocals int) error {
+	type instruction struct {
+		offset   int
+		def      *code.Definition
+		operands []int
+	}
+
+	instructions := []instruction{}
+	indexes := map[int]int{}
+	starts := map[int]bool{len(ins): true}
+	for i := 0; i < len(ins); {
+		def, err := code.Lookup(ins[i])
+		if err != nil {
+			return fmt.Errorf("invalid instruction at %04d: %s", i, err)
+		}
+
+		width := 0
+		for _, w := range def.OperandWidths {
+			width += w
+		}
+		if i+1+width > len(ins) {
+			return fmt.Errorf("truncated instruction %s at %04d", def.Name, i)
+		}
+
+		operands, _ := code.ReadOperands(def, ins[i+1:])
+		indexes[i] = len(instructions)
+		instructions = append(instructions, instruction{i, def, operands})
+		starts[i] = true
+		i += 1 + width
+	}
+
+	for _, in := range instructions {
+		var constant object.Object
+		switch code.Opcode(ins[in.offset]) {
+		case code.OpConstant, code.OpClosure, code.OpMember, code.OpImport:
+			if in.operands[0] >= len(b.Constants) {
+				return fmt.Errorf("%s at %04d refers to missing constant %d",
+					in.def.Name, in.offset, in.operands[0])
+			}
+			constant = b.Constants[in.operands[0]]
+		}
+
+		switch code.Opcode(ins[in.offset]) {
+		case code.OpClosure:
+			if _, ok := constant.(*object.CompiledFunction); !ok {
+				return fmt.Errorf("%s at %04d refers to constant %d, which is not a function",
+					in.def.Name, in.offset, in.operands[0])
+			}
+		case code.OpMember, code.OpImport:
+			if _, ok := constant.(*object.String); !ok {
+				return fmt.Errorf("%s at %04d refers to constant %d, which is not a string",
+					in.def.Name, in.offset, in.operands[0])
+			}
+		case code.OpGetGlobal, code.OpSetGlobal:
+			if in.operands[0] >= len(b.Globals) {
+				return fmt.Errorf("%s at %04d refers to missing global %d",
+					in.def.Name, in.offset, in.operands[0])
+			}
+		case code.OpGetLocal, code.OpSetLocal, code.OpCaptureLocal, code.OpJumpIfDefined:
+			if in.operands[0] >= numLocals {
+				return fmt.Errorf("%s at %04d refers to missing local %d",
+					in.def.Name, in.offset, in.operands[0])
+			}
+		case code.OpHash:
+			if in.operands[0]%2 != 0 {
+				return fmt.Errorf("%s at %04d takes %d values, which do not make pairs",
+					in.def.Name, in.offset, in.operands[0])
+			}
+		}
+
+		switch code.Opcode(ins[in.offset]) {
+		case code.OpJump, code.OpJumpNotTruthy, code.OpTry, code.OpJumpIfDefined:
+			target := in.operands[len(in.operands)-1]
+			if !starts[target] {
+				return fmt.Errorf("%s at %04d jumps to %04d, which is not the start of an instruction",
+					in.def.Name, in.offset, target)
+			}
+		}
+	}
+
+	// Follow every path through the function, recording the height of the
+	// stack above the locals at the start of each instruction. The paths
+	// that meet at an instruction must agree on it.
+	heights := map[int]int{}
+	work := []int{}
+	reach := func(offset, height int) error {
+		if offset == len(ins) {
+			return nil
+		}
+		if h, ok := heights[offset]; ok {
+			if h != height {
+				return fmt.Errorf("stack height at %04d is %d on one path and %d on another",
+					offset, h, height)
+			}
+			return nil
+		}
+		heights[offset] = height
+		work = append(work, offset)
+		return nil
+	}
+
+	if err := reach(0, 0); err != nil {
+		return err
+	}
+	for len(work) > 0 {
+		in := instructions[indexes[work[len(work)-1]]]
+		work = work[:len(work)-1]
+
+		op := code.Opcode(ins[in.offset])
+		pops, pushes := stackEffect(op, in.operands)
+		height := heights[in.offset]
+		if pops > height {
+			return fmt.Errorf("stack underflow at %04d: %s needs %d, stack has %d",
+				in.offset, in.def.Name, pops, height)
+		}
+		height += pushes - pops
+
+		successors := []int{}
+		switch op {
+		case code.OpReturnValue, code.OpReturn, code.OpThrow, code.OpRethrow:
+		case code.OpJump:
+			successors = append(successors, in.operands[0])
+		case code.OpJumpNotTruthy, code.OpJumpIfDefined:
+			successors = append(successors, in.operands[len(in.operands)-1])
+			fallthrough
+		default:
+			width := 0
+			for _, w := range in.def.OperandWidths {
+				width += w
+			}
+			successors = append(successors, in.offset+1+width)
+		}
+		for _, offset := range successors {
+			if err := reach(offset, height); err != nil {
+				return err
+			}
+		}
+
+		// The handler installed by OpTry starts with the stack as it was,
+		// plus the error raised.
+		if op == code.OpTry {
+			if err := reach(in.operands[0], height+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// stackEffect returns how many values the instruction op with operands
+// takes from the stack and how many it pushes.
+func stackEffect(op code.Opcode, operands []int) (pops, pushes int) {
+	switch op {
+	case code.OpConstant, code.OpTrue, code.OpFalse, code.OpNull,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetFree, code.OpCurrentClosure,
+		code.OpCaptureLocal, code.OpCaptureFree, code.OpImport:
+		return 0, 1
+	case code.OpPop, code.OpJumpNotTruthy, code.OpSetGlobal, code.OpSetLocal,
+		code.OpReturnValue, code.OpThrow, code.OpRethrow:
+		return 1, 0
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+		code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan,
+		code.OpIndex, code.OpHasKey:
+		return 2, 1
+	case code.OpMinus, code.OpBang, code.OpMember, code.OpMatchArray, code.OpMatchHash,
+		code.OpElement, code.OpRestOf, code.OpCatch:
+		return 1, 1
+	case code.OpArray, code.OpHash:
+		return operands[0], 1
+	case code.OpClosure:
+		return operands[1], 1
+	case code.OpCall:
+		return 1 + operands[0] + 2*operands[1], 1
+	case code.OpDestructureArray:
+		return 1, operands[0] + operands[1]
+	case code.OpDestructureHash:
+		return 1 + operands[0], operands[0]
+	default:
+		return 0, 0
+	}
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (w *encoder) uint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *encoder) int(v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *encoder) bool(v bool) {
+	if v {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *encoder) bytes(v []byte) {
+	w.uint(uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *encoder) string(v string) {
+	w.bytes([]byte(v))
+}
+
+func (w *encoder) strings(v []string) {
+	w.uint(uint64(len(v)))
+	for _, s := range v {
+		w.string(s)
+	}
+}
+
+func (w *encoder) sourceMap(m code.SourceMap) {
+	w.uint(uint64(len(m)))
+	for _, entry := range m {
+		w.uint(uint64(entry.Offset))
+		w.uint(uint64(entry.Pos.Line))
+		w.uint(uint64(entry.Pos.Column))
+	}
+}
+
+func (w *encoder) constant(constant object.Object) error {
+	switch constant := constant.(type) {
+	case *object.Integer:
+		w.buf.WriteByte(tagInteger)
+		w.int(constant.Value)
+	case *object.String:
+		w.buf.WriteByte(tagString)
+		w.string(constant.Value)
+	case *object.CompiledFunction:
+		w.buf.WriteByte(tagCompiledFunction)
+		w.string(constant.Name)
+		w.bytes(constant.Instructions)
+		w.sourceMap(constant.SourceMap)
+		w.uint(uint64(constant.NumLocals))
+		w.uint(uint64(constant.NumParameters))
+		w.uint(uint64(constant.NumRequired))
+		w.bool(constant.Variadic)
+		w.strings(constant.ParameterNames)
+		w.strings(constant.LocalNames)
+	default:
+		return fmt.Errorf("cannot serialize constant of type %s", constant.Type())
+	}
+	return nil
+}
+
+// decoder reads what encoder wrote. The first error sticks, and later reads
+// return zero values, so that callers only check err at the end.
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *decoder) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("malformed bytecode at byte %d: %s",
+			r.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *decoder) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad unsigned integer")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *decoder) int() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad integer")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *decoder) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *decoder) bool() bool {
+	return r.byte() == 1
+}
+
+// count reads a length and checks that it is not larger than the data left,
+// since every element takes at least one byte.
+func (r *decoder) count() int {
+	n := r.uint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("length %d exceeds the data", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *decoder) bytes() []byte {
+	n := r.count()
+	if r.err != nil {
+		return nil
+	}
+	v := make([]byte, n)
+	copy(v, r.data[r.pos:r.pos+n])
+	r.pos += n
+	return v
+}
+
+func (r *decoder) string() string {
+	return string(r.bytes())
+}
+
+func (r *decoder) strings() []string {
+	n := r.count()
+	v := make([]string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		v = append(v, r.string())
+	}
+	return v
+}
+
+func (r *decoder) sourceMap() code.SourceMap {
+	n := r.count()
+	m := make(code.SourceMap, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		m = append(m, code.SourcePosition{
+			Offset: int(r.uint()),
+			Pos: token.Position{
+				Line:   int(r.uint()),
+				Column: int(r.uint()),
+			},
+		})
+	}
+	return m
+}
+
+func (r *decoder) constant() object.Object {
+	switch tag := r.byte(); tag {
+	case tagInteger:
+		return &object.Integer{Value: r.int()}
+	case tagString:
+		return &object.String{Value: r.string()}
+	case tagCompiledFunction:
+		fn := &object.CompiledFunction{
+			Name:         r.string(),
+			Instructions: r.bytes(),
+			SourceMap:    r.sourceMap(),
+		}
+		fn.NumLocals = int(r.uint())
+		fn.NumParameters = int(r.uint())
+		fn.NumRequired = int(r.uint())
+		fn.Variadic = r.bool()
+		fn.ParameterNames = r.strings()
+		fn.LocalNames = r.strings()
+
+		// The parameters, and the rest parameter after them, take the first
+		// local slots.
+		numParameterSlots := fn.NumParameters
+		if fn.Variadic {
+			numParameterSlots++
+		}
+		if r.err == nil && (len(fn.ParameterNames) != fn.NumParameters ||
+			len(fn.LocalNames) != fn.NumLocals || fn.NumRequired > fn.NumParameters ||
+			numParameterSlots > fn.NumLocals) {
+			r.fail("inconsistent function %q", fn.Name)
+		}
+		return fn
+	default:
+		r.fail("unknown constant tag %d", tag)
+		return nil
+	}
+}
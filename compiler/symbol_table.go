@@ -0,0 +1,167 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable maps the names of one scope to the slots holding them. A
+// function gets its own table, whose slots are the locals of its frame. A
+// block table, used for the bindings of a match arm or a catch clause,
+// shares the slots of the table it is nested in, so that the names it
+// defines are only visible inside the block.
+//
+// A function nested in a scope also sees the names the scope binds after
+// the function, as it does in the evaluator. The set later holds those
+// names, and one that a nested function refers to gets its slot ahead of
+// its let.
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol
+
+	store map[string]Symbol
+	later map[string]bool
+	ahead map[string]Symbol
+	scope SymbolScope
+	block bool
+	names *[]string
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		store: map[string]Symbol{},
+		ahead: map[string]Symbol{},
+		scope: GlobalScope,
+		names: &[]string{},
+	}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	s.scope = LocalScope
+	return s
+}
+
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{
+		Outer: outer,
+		store: map[string]Symbol{},
+		ahead: map[string]Symbol{},
+		scope: outer.scope,
+		block: true,
+		names: outer.names,
+	}
+}
+
+// Define binds name in the table. Defining a name again in the same table
+// reuses its slot, as let does with the bindings of an environment.
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && symbol.Scope == s.scope {
+		return symbol
+	}
+	if symbol, ok := s.ahead[name]; ok {
+		delete(s.ahead, name)
+		s.store[name] = symbol
+		return symbol
+	}
+	return s.define(name)
+}
+
+// DefineParameter binds name to a new slot even if a parameter with the
+// same name was defined before, so that every parameter has its own slot.
+func (s *SymbolTable) DefineParameter(name string) Symbol {
+	return s.define(name)
+}
+
+func (s *SymbolTable) define(name string) Symbol {
+	symbol := Symbol{Name: name, Scope: s.scope, Index: len(*s.names)}
+	*s.names = append(*s.names, name)
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Scope: FunctionScope, Index: 0}
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	return s.resolve(name, false)
+}
+
+// resolve looks name up in s and the tables enclosing it. Once the lookup
+// has left a function, the names s binds later are found as well.
+func (s *SymbolTable) resolve(name string, nested bool) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if !ok && nested {
+		symbol, ok = s.defineAhead(name)
+	}
+	if ok || s.Outer == nil {
+		return symbol, ok
+	}
+
+	symbol, ok = s.Outer.resolve(name, nested || !s.block)
+	if !ok || s.block {
+		return symbol, ok
+	}
+
+	if symbol.Scope == GlobalScope {
+		return symbol, ok
+	}
+
+	return s.defineFree(symbol), true
+}
+
+// defineAhead gives name, which s binds later, its slot now. The global
+// table leaves such names to be defined where they are first used.
+func (s *SymbolTable) defineAhead(name string) (Symbol, bool) {
+	if symbol, ok := s.ahead[name]; ok {
+		return symbol, true
+	}
+	if !s.later[name] || s.scope == GlobalScope {
+		return Symbol{}, false
+	}
+
+	symbol := Symbol{Name: name, Scope: s.scope, Index: len(*s.names)}
+	*s.names = append(*s.names, name)
+	s.ahead[name] = symbol
+	return symbol, true
+}
+
+// NumDefinitions returns the number of slots used by the frame of s.
+func (s *SymbolTable) NumDefinitions() int {
+	return len(*s.names)
+}
+
+// Names returns the names bound to the slots of the frame of s, by index.
+func (s *SymbolTable) Names() []string {
+	return *s.names
+}
+
+// Global returns the table of the global scope.
+func (s *SymbolTable) Global() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package evaluator
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+// environmentSize is what a function call is charged for its environment.
+const environmentSize = 64
+
+// charge evaluates node with evalNode, counting the step and the object the
+// node allocates against the budget of env, if it has one.
+func charge(
+	node ast.Node,
+	env *object.Environment,
+	evalNode func(ast.Node, *object.Environment) object.Object,
+) object.Object {
+	budget := env.Budget()
+	if budget == nil {
+		return evalNode(node, env)
+	}
+
+	if err := budget.Step(); err != nil {
+		return err
+	}
+
+	result := evalNode(node, env)
+	if allocates(node) {
+		if err := allocate(budget, result); err != nil {
+			return err
+		}
+	}
+	return result
+}
+
+// allocates reports whether evaluating node creates a new object.
+func allocates(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.ArrayLiteral, *ast.HashLiteral,
+		*ast.FunctionLiteral, *ast.InfixExpression, *ast.PrefixExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+func allocate(budget *object.Budget, obj object.Object) *object.Error {
+	if size := object.SizeOf(obj); size > 0 {
+		return budget.Allocate(size)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package evaluator
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+// bindPattern destructures value through pattern into env. Unlike
+// matchPattern, a value whose shape does not fit the pattern is an error.
+func bindPattern(
+	pattern ast.Pattern,
+	value object.Object,
+	env *object.Environment,
+) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return nil
+	case *ast.IdentifierPattern:
+		define(pattern.Name, value, env)
+		return nil
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return newError("cannot destructure %s as %s", value.Type(), object.ArrayObject)
+		}
+		return bindElements(pattern.Elements, array.Elements, env)
+	case *ast.HashPattern:
+		return bindHashPattern(pattern, value, env)
+	default:
+		return newError("cannot bind to pattern: %s", pattern.String())
+	}
+}
+
+func bindElements(
+	patterns []ast.Pattern,
+	values []object.Object,
+	env *object.Environment,
+) *object.Error {
+	fixed, rest := ast.SplitRest(patterns)
+
+	if rest == nil && len(values) != len(fixed) {
+		return newError("array pattern expects %d elements, got %d",
+			len(fixed), len(values))
+	}
+	if rest != nil && len(values) < len(fixed) {
+		return newError("array pattern expects at least %d elements, got %d",
+			len(fixed), len(values))
+	}
+
+	for i, element := range fixed {
+		if err := bindPattern(element, values[i], env); err != nil {
+			return err
+		}
+	}
+
+	if rest != nil {
+		define(rest.Name, restArray(values, len(fixed)), env)
+	}
+
+	return nil
+}
+
+func bindHashPattern(
+	pattern *ast.HashPattern,
+	value object.Object,
+	env *object.Environment,
+) *object.Error {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return newError("cannot destructure %s as %s", value.Type(), object.HashObject)
+	}
+
+	for _, pair := range pattern.Pairs {
+		key := Eval(pair.Key, env)
+		if err, ok := key.(*object.Error); ok {
+			return err
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		found, ok := hash.Pairs[hashKey.HashKey()]
+		if !ok {
+			return newError("key not found in hash: %s", key.Inspect())
+		}
+
+		if err := bindPattern(pair.Value, found.Value, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindParameters binds a call's arguments to the parameters of a function.
+// Named arguments fill the parameters that were not given positionally, and
+// defaults are evaluated in env so that they can refer to earlier parameters.
+func bindParameters(
+	params []ast.Pattern,
+	args []object.Object,
+	named map[string]object.Object,
+	env *object.Environment,
+) *object.Error {
+	fixed, rest := ast.SplitRest(params)
+
+	required := 0
+	for _, param := range fixed {
+		if _, ok := param.(*ast.DefaultPattern); !ok {
+			required++
+		}
+	}
+
+	given := len(args) + len(named)
+	if given < required || (rest == nil && len(args) > len(fixed)) {
+		return arityError(required, len(fixed), rest != nil, given)
+	}
+
+	for i, param := range fixed {
+		target, defaultValue := param, ast.Expression(nil)
+		if pattern, ok := param.(*ast.DefaultPattern); ok {
+			target, defaultValue = pattern.Target, pattern.Default
+		}
+
+		name := ""
+		if ident, ok := target.(*ast.IdentifierPattern); ok {
+			name = ident.Name.Value
+		}
+		namedValue, hasNamed := named[name]
+		delete(named, name)
+
+		var value object.Object
+		switch {
+		case i < len(args):
+			if hasNamed {
+				return newError("multiple values for parameter %s", name)
+			}
+			value = args[i]
+		case hasNamed:
+			value = namedValue
+		case defaultValue != nil:
+			value = Eval(defaultValue, env)
+			if err, ok := value.(*object.Error); ok {
+				return err
+			}
+		case name != "":
+			return newError("missing argument for parameter %s", name)
+		default:
+			return arityError(required, len(fixed), rest != nil, given)
+		}
+
+		if err := bindPattern(target, value, env); err != nil {
+			return err
+		}
+	}
+
+	for name := range named {
+		return newError("unknown parameter: %s", name)
+	}
+
+	if rest != nil {
+		from := len(fixed)
+		if len(args) < from {
+			from = len(args)
+		}
+		define(rest.Name, restArray(args, from), env)
+	}
+
+	return nil
+}
+
+func arityError(required, total int, variadic bool, got int) *object.Error {
+	switch {
+	case variadic:
+		return newError("wrong number of arguments: want at least %d, got=%d",
+			required, got)
+	case required == total:
+		return newError("wrong number of arguments: want=%d, got=%d",
+			required, got)
+	default:
+		return newError("wrong number of arguments: want %d to %d, got=%d",
+			required, total, got)
+	}
+}
+
+func restArray(values []object.Object, from int) *object.Array {
+	elements := make([]object.Object, len(values)-from)
+	copy(elements, values[from:])
+	return &object.Array{Elements: elements}
+}
@@ -1,16 +1,18 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/computerphilosopher/monkey-interpreter/ast"
 	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
 )
 
 var (
-	Null  = &object.Null{}
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
+	Null  = object.NullValue
+	True  = object.TrueValue
+	False = object.FalseValue
 )
 
 func isError(obj object.Object) bool {
@@ -20,7 +22,36 @@ func isError(obj object.Object) bool {
 	return obj.Type() == object.ErrorObject
 }
 
+// Eval evaluates node in env. An error raised while evaluating node is
+// stamped with the position of the innermost node that carries a token.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := charge(node, env, eval)
+	if err, ok := result.(*object.Error); ok && err.Pos == (token.Position{}) {
+		if tok, ok := nodeToken(node); ok {
+			err.Pos = tok.Pos
+		}
+	}
+	return result
+}
+
+// EvalContext evaluates node in env like Eval, but stops with a
+// CanceledError once ctx is done. The context is checked at every function
+// call.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	budget := env.Budget()
+	if budget == nil {
+		budget = object.NewBudget(object.Limits{})
+		env.SetBudget(budget)
+		defer env.SetBudget(nil)
+	}
+
+	budget.SetContext(ctx)
+	defer budget.SetContext(nil)
+
+	return Eval(node, env)
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node.Statements, env)
@@ -61,7 +92,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			if err := bindPattern(node.Pattern, val, env); err != nil {
+				return err
+			}
+			return nil
+		}
+		define(node.Name, val, env)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
@@ -72,6 +113,42 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			Env:        env,
 			Body:       body,
 		}
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		named, err := evalNamedArguments(node.NamedArguments, env)
+		if err != nil {
+			return err
+		}
+		return applyFunction(function, args, named, env.Budget())
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+	case *ast.ThrowStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return object.NewThrownError(val)
 	}
 
 	return nil
@@ -105,6 +182,11 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 			}
 		}
 	}
+
+	// A block that is empty or ends with a let has no value.
+	if result == nil {
+		return Null
+	}
 	return result
 }
 
@@ -158,6 +240,8 @@ func evalInfixExpression(
 	switch {
 	case left.Type() == object.IntegerObject && right.Type() == object.IntegerObject:
 		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.StringObject && right.Type() == object.StringObject:
+		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -188,6 +272,9 @@ func evalIntegerInfixExpression(
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
@@ -204,6 +291,27 @@ func evalIntegerInfixExpression(
 	}
 }
 
+func evalStringInfixExpression(
+	operator string, left, right object.Object,
+) object.Object {
+
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type(),
+		)
+	}
+}
+
 func evalIfExpression(exp *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(exp.Condition, env)
 	if isError(condition) {
@@ -232,9 +340,133 @@ func evalIdentifier(
 	node *ast.Identifier,
 	env *object.Environment,
 ) object.Object {
-	val, ok := env.Get(node.Value)
-	if !ok {
+	if binding := node.Binding; binding != nil {
+		if val, ok := env.GetSlot(binding.Depth, binding.Slot); ok {
+			return val
+		}
 		return newError("identifier not found: " + node.Value)
 	}
-	return val
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	return newError("identifier not found: " + node.Value)
+}
+
+// define binds name to val in env, in the slot given by the resolver if
+// it is a local.
+func define(name *ast.Identifier, val object.Object, env *object.Environment) {
+	if name.Binding == nil {
+		env.Set(name.Value, val)
+		return
+	}
+	if val == nil {
+		val = Null
+	}
+	env.SetSlot(name.Binding.Slot, val)
+}
+
+func evalExpressions(
+	exps []ast.Expression,
+	env *object.Environment,
+) []object.Object {
+	result := []object.Object{}
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+func evalHashLiteral(
+	node *ast.HashLiteral,
+	env *object.Environment,
+) object.Object {
+	pairs := map[object.HashKey]object.HashPair{}
+
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}
+}
+
+func applyBuiltin(
+	builtin *object.Builtin,
+	args []object.Object,
+	named map[string]object.Object,
+) object.Object {
+	if len(named) != 0 {
+		return newError("builtin %s does not accept named arguments", builtin.Name)
+	}
+
+	return builtin.Call(args...)
+}
+
+func extendFunctionEnv(
+	fn *object.Function,
+	args []object.Object,
+	named map[string]object.Object,
+	budget *object.Budget,
+) (*object.Environment, *object.Error) {
+	env := object.NewEnclosedEnvironment(fn.Env)
+	if budget != nil {
+		env.SetBudget(budget)
+	}
+
+	if err := bindParameters(fn.Parameters, args, named, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func evalNamedArguments(
+	args []*ast.NamedArgument,
+	env *object.Environment,
+) (map[string]object.Object, object.Object) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	named := map[string]object.Object{}
+	for _, arg := range args {
+		if _, ok := named[arg.Name.Value]; ok {
+			return nil, newError("duplicate named argument: %s", arg.Name.Value)
+		}
+
+		evaluated := Eval(arg.Value, env)
+		if isError(evaluated) {
+			return nil, evaluated
+		}
+		named[arg.Name.Value] = evaluated
+	}
+
+	return named, nil
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
 }
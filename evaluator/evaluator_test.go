@@ -1,14 +1,34 @@
 package evaluator
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/computerphilosopher/monkey-interpreter/compiler"
 	"github.com/computerphilosopher/monkey-interpreter/lexer"
 	"github.com/computerphilosopher/monkey-interpreter/object/object"
 	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/resolver"
+	"github.com/computerphilosopher/monkey-interpreter/vm"
 	"github.com/stretchr/testify/assert"
 )
 
+// useVM makes testEval compile the input and run it on the VM instead of
+// evaluating it, so that every case checks that both engines agree.
+var useVM = false
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 {
+		useVM = true
+		code = m.Run()
+	}
+	os.Exit(code)
+}
+
 func TestEvaluatorIntegerExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -94,6 +114,10 @@ func TestIfElseExpression(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (true) {}", nil},
+		{"if (true) { let x = 1 }", nil},
+		{"let f = fn() { if (true) {} }; f()", nil},
+		{"let f = fn() {}; f()", nil},
 	}
 
 	for _, tt := range tests {
@@ -149,6 +173,10 @@ func TestErrorHandling(t *testing.T) {
 			"-true",
 			"unknown operator: -Boolean",
 		},
+		{
+			"let zero = 0; 1 / zero",
+			"division by zero",
+		},
 		{
 			"true + false",
 			"unknown operator: Boolean + Boolean",
@@ -172,6 +200,10 @@ func TestErrorHandling(t *testing.T) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			"1 + if (true) {}",
+			"type mismatch: Integer + Null",
+		},
 	}
 
 	for _, tt := range tests {
@@ -202,6 +234,10 @@ func TestLetStatements(t *testing.T) {
 }
 
 func TestFunctionObject(t *testing.T) {
+	if useVM {
+		t.Skip("the VM represents functions as closures")
+	}
+
 	input := "fn(x) { x + 2; };"
 	evaluated := testEval(input)
 
@@ -211,17 +247,81 @@ func TestFunctionObject(t *testing.T) {
 	assert.Equal(t, 1, len(fn.Parameters))
 	assert.Equal(t, "x", fn.Parameters[0].String())
 
-	expectedBody := "(x + 2)"
+	expectedBody := "{ (x + 2) }"
 
 	assert.Equal(t, expectedBody, fn.Body.String())
 
 }
 
 func testEval(input string) object.Object {
+	return testEvalWithImporter(input, nil)
+}
+
+func testEvalWithImporter(input string, importer object.Importer) object.Object {
 	l := lexer.NewLexer(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	return Eval(program, object.NewEnvironment())
+
+	if errs := resolver.Resolve(program, nil); len(errs) != 0 {
+		return newError("%s", errs[0].(*resolver.Error).Message)
+	}
+
+	if !useVM {
+		env := object.NewEnvironment()
+		if importer != nil {
+			env.SetImporter(importer)
+		}
+		return Eval(program, env)
+	}
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return newError("%s", err)
+	}
+
+	machine := vm.New(c.Bytecode())
+	if importer != nil {
+		machine.SetImporter(importer)
+	}
+	if err := machine.Run(); err != nil {
+		if errObj, ok := err.(*object.Error); ok {
+			return errObj
+		}
+		return newError("%s", err)
+	}
+	return machine.LastPoppedStackElem()
+}
+
+// testEvalContext evaluates input in env like EvalContext, or runs it on a VM
+// charged to the budget of env.
+func testEvalContext(ctx context.Context, input string, env *object.Environment) object.Object {
+	program := parser.New(lexer.NewLexer(input)).ParseProgram()
+	if errs := resolver.Resolve(program, env.Names()); len(errs) != 0 {
+		return newError("%s", errs[0].(*resolver.Error).Message)
+	}
+
+	if !useVM {
+		return EvalContext(ctx, program, env)
+	}
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return newError("%s", err)
+	}
+
+	machine := vm.New(c.Bytecode())
+	machine.SetBudget(env.Budget())
+	if err := machine.RunContext(ctx); err != nil {
+		return err.(*object.Error)
+	}
+	return machine.LastPoppedStackElem()
+}
+
+func testModuleLoader(dir string, searchPath []string) *ModuleLoader {
+	if useVM {
+		return NewModuleLoaderWithRunner(dir, searchPath, vm.RunModule)
+	}
+	return NewModuleLoader(dir, searchPath)
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) {
@@ -239,3 +339,415 @@ func testBooleanObject(t *testing.T, obj object.Object, expected bool) {
 func testNullObject(t *testing.T, obj object.Object) {
 	assert.Equal(t, Null, obj)
 }
+
+func TestStringConcatenation(t *testing.T) {
+	evaluated := testEval(`"Hello" + " " + "World!"`)
+
+	str, ok := evaluated.(*object.String)
+	assert.True(t, ok)
+	assert.Equal(t, "Hello World!", str.Value)
+}
+
+func TestHashLiteral(t *testing.T) {
+	input := `{"one": 1, "two": 2, 3: 3, true: 4}`
+
+	evaluated := testEval(input)
+	hash, ok := evaluated.(*object.Hash)
+	assert.True(t, ok)
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey(): 1,
+		(&object.String{Value: "two"}).HashKey(): 2,
+		(&object.Integer{Value: 3}).HashKey():    3,
+		True.HashKey():                           4,
+	}
+
+	assert.Equal(t, len(expected), len(hash.Pairs))
+	for key, value := range expected {
+		pair, ok := hash.Pairs[key]
+		assert.True(t, ok)
+		testIntegerObject(t, pair.Value, value)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	arms := `{ 0 => "zero", [a, b] => a + b, {"k": v} => v, _ => "other" }`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"match (0) " + arms, "zero"},
+		{"match ([1, 2]) " + arms, 3},
+		{"match ([1, 2, 3]) " + arms, "other"},
+		{`match ({"k": 7, "j": 8}) ` + arms, 7},
+		{`match ({"j": 8}) ` + arms, "other"},
+		{"match (true) " + arms, "other"},
+		{`match ("zero") { 0 => 1, "zero" => 2 }`, 2},
+		{"match (-1) { -1 => 1, _ => 2 }", 1},
+		{"match (5) { x if x > 3 => x * 2, x => x }", 10},
+		{"match (2) { x if x > 3 => x * 2, x => x }", 2},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }", 6},
+		{"match (1) { 2 => 2 }", nil},
+		{"let x = 1; match (2) { x => x }; x", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			assert.True(t, ok)
+			assert.Equal(t, expected, str.Value)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(2);", 4},
+		{"let x = 1; let f = fn() { let y = x; let x = 2; y + x }; f()", 3},
+		{"let f = fn(a) { match (a) { [b, ...c] => fn(d) { let [x, y] = c; b + x + y + d } } }; f([1, 2, 3])(4)", 10},
+		{"let f = fn() { try { throw 5 } catch ({value}) { fn() { value } } }; f()()", 5},
+		{"let f = fn(x) { let g = fn() { x }; let x = 5; g() }; f(1)", 5},
+		{"let f = fn(x) { let g = fn() { fn() { x } }; let x = x + 5; g()() }; f(1)", 6},
+		{"let f = fn(a, b = fn() { a }) { let a = 2; b() }; f(1)", 2},
+		{"let f = fn(n) { let g = fn() { n }; if (n == 0) { g() } else { f(n - 1) + g() } }; f(3)", 6},
+		{"let f = fn() { let g = fn() { h() }; let h = fn() { 3 }; g() }; f()", 3},
+		{"let h = 1; let f = fn() { let g = fn() { h }; let a = h; let h = 2; a + g() }; f()", 3},
+		{"let f = fn() { let g = fn() { fn() { k } }; let [k] = [4]; g()() }; f()", 4},
+		{"let k = 1; let f = fn(a) { let g = fn() { k }; match (a) { x => if (true) { let k = 5; g() + x } } }; f(10)", 11},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(10)", 0},
+		{"let f = fn(n, acc) { if (n == 0) { return acc }; return f(n - 1, acc + n) }; f(10, 0)", 55},
+		{"let f = fn(n) { match (n) { 0 => 7, _ => f(n - 1) } }; f(10)", 7},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; if (even(10)) { 1 } else { 0 }", 1},
+		{"let f = fn(n) { try { if (n == 0) { throw 5 } else { f(n - 1) } } catch ({value}) { value + n } }; f(3)", 5},
+		{"let f = fn(n) { try { return g(n) } finally { 1 } }; let g = fn(n) { n * 2 }; f(4)", 8},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestDeepTailRecursion(t *testing.T) {
+	inputs := []string{
+		"let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } }; countdown(1000000)",
+		"let countdown = fn(n) { if (n > 0) { return countdown(n - 1) }; 0 }; countdown(200000)",
+		"let countdown = fn(n) { match (n) { 0 => 0, _ => countdown(n - 1) } }; countdown(200000)",
+	}
+
+	for _, input := range inputs {
+		testIntegerObject(t, testEval(input), 0)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   object.Limits
+		expected string
+	}{
+		{"let f = fn(n) { 1 + f(n + 1) }; f(0)", object.Limits{MaxDepth: 100},
+			"maximum call depth exceeded: 100"},
+		{"let f = fn(n) { f(n + 1) }; f(0)", object.Limits{MaxSteps: 1000},
+			"step limit exceeded: 1000"},
+		{"let f = fn(a) { f([a]) }; f([])", object.Limits{MaxAllocations: 1000},
+			"allocation limit exceeded: 1000 objects"},
+		{`let f = fn(s) { f(s + s) }; f("a")`, object.Limits{MaxBytes: 1 << 16},
+			"memory limit exceeded: 65536 bytes"},
+		{"let f = fn(n) { 1 + f(n + 1) }; try { f(0) } catch (e) { 0 } finally { 1 }",
+			object.Limits{MaxDepth: 100}, "maximum call depth exceeded: 100"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.SetBudget(object.NewBudget(tt.limits))
+
+		errObj, ok := testEvalContext(context.Background(), tt.input, env).(*object.Error)
+		if assert.True(t, ok, tt.input) {
+			assert.Equal(t, object.LimitError, errObj.Kind, tt.input)
+			assert.Equal(t, tt.expected, errObj.Message, tt.input)
+		}
+	}
+
+	env := object.NewEnvironment()
+	env.SetBudget(object.NewBudget(object.Limits{MaxDepth: 10}))
+	testIntegerObject(t, testEvalContext(context.Background(),
+		"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(500)", env), 0)
+}
+
+func TestEvalContext(t *testing.T) {
+	loop := "let f = fn(n) { f(n + 1) }; f(0)"
+	tests := []struct {
+		input    string
+		timeout  time.Duration
+		expected string
+	}{
+		{loop, 20 * time.Millisecond, "context deadline exceeded"},
+		{"let f = fn(n) { try { g(n) } catch (e) { 0 } }; let g = fn(n) { g(n + 1) }; f(0)",
+			20 * time.Millisecond, "context deadline exceeded"},
+		{loop, 0, "context canceled"},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		if tt.timeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+		} else {
+			cancel()
+		}
+
+		env := object.NewEnvironment()
+
+		errObj, ok := testEvalContext(ctx, tt.input, env).(*object.Error)
+		cancel()
+		if assert.True(t, ok, tt.input) {
+			assert.Equal(t, object.CanceledError, errObj.Kind, tt.input)
+			assert.Equal(t, tt.expected, errObj.Message, tt.input)
+		}
+		assert.Nil(t, env.Budget(), tt.input)
+	}
+
+	testIntegerObject(t, testEvalContext(context.Background(),
+		"let f = fn(x) { x * 2 }; f(21)", object.NewEnvironment()), 42)
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let [a, b] = [1, 2]; a + b", 3},
+		{"let [a, _, c] = [1, 2, 3]; a + c", 4},
+		{"let [a, ...rest] = [1, 2, 3]; let [b, c] = rest; a + b + c", 6},
+		{"let [a, ...rest] = [1]; let [] = rest; a", 1},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", 6},
+		{`let {name, age} = {"name": 1, "age": 2, "other": 3}; name + age`, 3},
+		{`let {"inner": [x, y]} = {"inner": [4, 5]}; x * y`, 20},
+		{"let sum = fn([a, b]) { a + b }; sum([3, 4])", 7},
+		{`let age = fn({age}) { age }; age({"age": 30})`, 30},
+		{"let count = fn(first, ...rest) { let [a, b] = rest; first + a + b }; count(1, 2, 3)", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let [a, b] = 1;", "cannot destructure Integer as Array"},
+		{"let [a, b] = [1];", "array pattern expects 2 elements, got 1"},
+		{"let [a, b, ...c] = [1];", "array pattern expects at least 2 elements, got 1"},
+		{`let {name} = [1];`, "cannot destructure Array as Hash"},
+		{`let {name} = {"age": 1};`, "key not found in hash: name"},
+		{"let f = fn(a, b) { a }; f(1);", "wrong number of arguments: want=2, got=1"},
+		{"let f = fn([a]) { a }; f([1, 2]);", "array pattern expects 1 elements, got 2"},
+		{"let x = 1; x(1);", "not a function: Integer"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn(a, b = 10) { a + b }; f(1)", 11},
+		{"let f = fn(a, b = 10) { a + b }; f(1, 2)", 3},
+		{"let f = fn(a, b = a * 2) { a + b }; f(3)", 9},
+		{"let x = 5; let f = fn(a = x) { a }; let x = 6; f()", 6},
+		{"let f = fn(a, ...rest) { let [b, c] = rest; a + b + c }; f(1, 2, 3)", 6},
+		{"let f = fn(a, ...rest) { match (rest) { [] => a } }; f(1)", 1},
+		{"let f = fn(a, b = 2, c = 3) { a * 100 + b * 10 + c }; f(1, c: 5)", 125},
+		{"let f = fn(a, b) { a - b }; f(b: 1, a: 5)", 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionParameterErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let f = fn(a, b = 1) { a }; f();", "wrong number of arguments: want 1 to 2, got=0"},
+		{"let f = fn(a, b = 1) { a }; f(1, 2, 3);", "wrong number of arguments: want 1 to 2, got=3"},
+		{"let f = fn(a, ...rest) { a }; f();", "wrong number of arguments: want at least 1, got=0"},
+		{"let f = fn(a, b) { a }; f(1, c: 2);", "missing argument for parameter b"},
+		{"let f = fn(a, b = 1) { a }; f(1, c: 2);", "unknown parameter: c"},
+		{"let f = fn(a) { a }; f(1, a: 2);", "multiple values for parameter a"},
+		{"let f = fn(a) { a }; f(a: 1, a: 2);", "duplicate named argument: a"},
+		{"let f = fn(a = b) { a }; f();", "identifier not found: b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	files := map[string]string{
+		"lib/math.mk": `export let add = fn(a, b) { a + b }; let hidden = 1; export let [one, two] = [1, 2];`,
+		"lib/uses.mk": `import "math.mk" as math; export let three = math.add(math.one, math.two);`,
+		"cycle_a.mk":  `import "cycle_b.mk" as b;`,
+		"cycle_b.mk":  `import "cycle_a.mk" as a;`,
+		"broken.mk":   `export let x = 1 +;`,
+		"helpers.mk": `let add = fn(a, b) { a + b }; export let y = 2;
+			export let twice = fn(x) { add(x, x) }; export let g = fn() { y };`,
+	}
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(source), 0644))
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "lib/math.mk" as math; math.add(2, 3)`, 5},
+		{`import "uses.mk" as uses; uses.three`, 3},
+		{`import "lib/math.mk" as a; import "math.mk" as b; a == b`, true},
+		{`import "lib/math.mk" as math; math.hidden`,
+			"module " + filepath.Join(lib, "math.mk") + " has no export hidden"},
+		{`import "missing.mk" as m;`, "module not found: missing.mk"},
+		{`import "cycle_a.mk" as a;`, "import cycle: " + filepath.Join(dir, "cycle_a.mk") +
+			" -> " + filepath.Join(dir, "cycle_b.mk") + " -> " + filepath.Join(dir, "cycle_a.mk")},
+		{`let x = 1; x.y`, "cannot access member y of Integer"},
+		{`let add = 0; let y = 5; import "helpers.mk" as h; h.twice(h.g())`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithImporter(tt.input, testModuleLoader(dir, []string{lib}))
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			assert.True(t, ok)
+			assert.Equal(t, expected, errObj.Message)
+		}
+	}
+
+	evaluated := testEval(`import "lib/math.mk" as math;`)
+	errObj, ok := evaluated.(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "cannot import lib/math.mk: no module loader", errObj.Message)
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { throw "oops"; 1 } catch ({message}) { message }`, "oops"},
+		{`try { throw 42 } catch ({value}) { value }`, 42},
+		{`try { throw 42 } catch ({message}) { message }`, "42"},
+		{`try { 5 + true } catch ({message}) { message }`, "type mismatch: Integer + Boolean"},
+		{`try { 1 / 0 } catch ({message}) { message }`, "division by zero"},
+		{`try { -true } catch ({value}) { value }`, nil},
+		{"try {\n  1;\n  -true\n} catch ({line, column}) { [line, column] }", []int64{3, 3}},
+		{"try {\n  throw 1\n} catch ({line, column}) { [line, column] }", []int64{2, 3}},
+		{`let f = fn() { throw "inner" }; try { f() } catch ({message}) { message }`, "inner"},
+		{`try { try { throw 1 } finally { 2 } } catch ({value}) { value + 10 }`, 11},
+		{`let x = try { 1 } finally { 2 }; x`, 1},
+		{`let f = fn() { try { return 1 } finally { 2 }; 3 }; f()`, 1},
+		{`let f = fn() { try { 1 } finally { return 2 } }; f()`, 2},
+		{`try { throw 1 } catch ({value}) { try { throw value + 1 } catch ({value}) { value } }`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			assert.True(t, ok, tt.input)
+			assert.Equal(t, expected, str.Value)
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			assert.True(t, ok, tt.input)
+			for i, value := range expected {
+				testIntegerObject(t, array.Elements[i], value)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestUncaughtErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{`throw "oops"`, "oops", 1, 1},
+		{`try { throw "oops" } catch ({message}) { throw message + "!" }`, "oops!", 1, 42},
+		{`try { 1 } finally { throw 2 }`, "2", 1, 21},
+		{"let a = 1;\nlet b = a + true;", "type mismatch: Integer + Boolean", 2, 11},
+		{`try { throw 1 } catch ([a]) { a }`, "cannot destructure Hash as Array", 1, 1},
+		{"let f = fn(a) { a };\nlet g = fn() { f() };\ng()", "wrong number of arguments: want=1, got=0", 2, 17},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expectedMessage, errObj.Message)
+		assert.Equal(t, tt.expectedLine, errObj.Pos.Line)
+		assert.Equal(t, tt.expectedColumn, errObj.Pos.Column)
+	}
+}
@@ -0,0 +1,70 @@
+package evaluator
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+func evalTryExpression(
+	exp *ast.TryExpression,
+	env *object.Environment,
+) object.Object {
+	result := Eval(exp.Block, env)
+
+	// A script must not be able to recover from exceeding its limits or
+	// from being canceled, so neither the catch clause nor the finally block
+	// runs.
+	if err, ok := result.(*object.Error); ok && err.Kind != object.RuntimeError {
+		return err
+	}
+
+	if err, ok := result.(*object.Error); ok && exp.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if bindErr := bindPattern(exp.Parameter, err.ToHash(), catchEnv); bindErr != nil {
+			result = bindErr
+		} else {
+			result = Eval(exp.Catch, catchEnv)
+		}
+	}
+
+	if exp.Finally != nil {
+		finally := Eval(exp.Finally, env)
+		if finally != nil &&
+			(finally.Type() == object.ErrorObject || finally.Type() == object.ReturnValueObject) {
+			return finally
+		}
+	}
+
+	return result
+}
+
+// nodeToken returns the token of the nodes that can raise an error.
+func nodeToken(node ast.Node) (token.Token, bool) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return node.Token, true
+	case *ast.PrefixExpression:
+		return node.Token, true
+	case *ast.InfixExpression:
+		return node.Token, true
+	case *ast.CallExpression:
+		return node.Token, true
+	case *ast.MemberExpression:
+		return node.Token, true
+	case *ast.HashLiteral:
+		return node.Token, true
+	case *ast.MatchExpression:
+		return node.Token, true
+	case *ast.LetStatement:
+		return node.Token, true
+	case *ast.ImportStatement:
+		return node.Token, true
+	case *ast.ThrowStatement:
+		return node.Token, true
+	case *ast.TryExpression:
+		return node.Token, true
+	default:
+		return token.Token{}, false
+	}
+}
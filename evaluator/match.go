@@ -0,0 +1,159 @@
+package evaluator
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+func evalMatchExpression(
+	exp *ast.MatchExpression,
+	env *object.Environment,
+) object.Object {
+	return evalMatch(exp, env, Eval)
+}
+
+// evalMatch evaluates the body of the first arm that matches with evalBody.
+func evalMatch(
+	exp *ast.MatchExpression,
+	env *object.Environment,
+	evalBody func(ast.Node, *object.Environment) object.Object,
+) object.Object {
+	subject := Eval(exp.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range exp.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return evalBody(arm.Body, armEnv)
+	}
+
+	return Null
+}
+
+// matchPattern reports whether value matches pattern, binding the captured
+// names into env as it goes.
+func matchPattern(
+	pattern ast.Pattern,
+	value object.Object,
+	env *object.Environment,
+) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.IdentifierPattern:
+		define(pattern.Name, value, env)
+		return true, nil
+	case *ast.LiteralPattern:
+		expected := Eval(pattern.Value, env)
+		if err, ok := expected.(*object.Error); ok {
+			return false, err
+		}
+		return objectsEqual(expected, value), nil
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, value, env)
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, value, env)
+	default:
+		return false, newError("unknown pattern: %s", pattern.String())
+	}
+}
+
+func matchArrayPattern(
+	pattern *ast.ArrayPattern,
+	value object.Object,
+	env *object.Environment,
+) (bool, *object.Error) {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+
+	fixed, rest := ast.SplitRest(pattern.Elements)
+	if len(array.Elements) < len(fixed) ||
+		(rest == nil && len(array.Elements) != len(fixed)) {
+		return false, nil
+	}
+
+	for i, element := range fixed {
+		matched, err := matchPattern(element, array.Elements[i], env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if rest != nil {
+		define(rest.Name, restArray(array.Elements, len(fixed)), env)
+	}
+
+	return true, nil
+}
+
+func matchHashPattern(
+	pattern *ast.HashPattern,
+	value object.Object,
+	env *object.Environment,
+) (bool, *object.Error) {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return false, nil
+	}
+
+	for _, pair := range pattern.Pairs {
+		key := Eval(pair.Key, env)
+		if err, ok := key.(*object.Error); ok {
+			return false, err
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return false, newError("unusable as hash key: %s", key.Type())
+		}
+
+		found, ok := hash.Pairs[hashKey.HashKey()]
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := matchPattern(pair.Value, found.Value, env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func objectsEqual(left, right object.Object) bool {
+	if left.Type() != right.Type() {
+		return false
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		return left.Value == right.(*object.Integer).Value
+	case *object.String:
+		return left.Value == right.(*object.String).Value
+	default:
+		return left == right
+	}
+}
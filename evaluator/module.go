@@ -0,0 +1,267 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/resolver"
+)
+
+// ModuleLoader imports modules from the file system. A module path is looked
+// up relative to the importing file first and then in each directory of the
+// search path. Every module is run once, and later imports of the same file
+// share the result.
+type ModuleLoader struct {
+	dir        string
+	searchPath []string
+	state      *loaderState
+}
+
+type loaderState struct {
+	modules map[string]*object.Module
+	loading []string
+	run     ModuleRunner
+	budget  *object.Budget
+}
+
+// ModuleRunner runs the program of a module, resolving its own imports with
+// importer, and returns the values it exports.
+type ModuleRunner func(
+	program *ast.Program,
+	importer object.Importer,
+) (map[string]object.Object, error)
+
+// NewModuleLoader returns a loader resolving relative imports against dir,
+// which evaluates each module in its own environment.
+func NewModuleLoader(dir string, searchPath []string) *ModuleLoader {
+	loader := NewModuleLoaderWithRunner(dir, searchPath, nil)
+	state := loader.state
+	state.run = func(program *ast.Program, importer object.Importer) (map[string]object.Object, error) {
+		return evalModule(program, importer, state.budget)
+	}
+	return loader
+}
+
+// NewModuleLoaderWithRunner returns a loader that runs modules with run,
+// so that they can be executed by another engine than the evaluator.
+func NewModuleLoaderWithRunner(dir string, searchPath []string, run ModuleRunner) *ModuleLoader {
+	return &ModuleLoader{
+		dir:        dir,
+		searchPath: searchPath,
+		state: &loaderState{
+			modules: map[string]*object.Module{},
+			run:     run,
+		},
+	}
+}
+
+// SetBudget sets the budget charged for evaluating the modules, so that
+// importing a module does not escape the limits of the importing script.
+func (loader *ModuleLoader) SetBudget(budget *object.Budget) {
+	loader.state.budget = budget
+}
+
+func (loader *ModuleLoader) Import(path string) (*object.Module, error) {
+	resolved, err := loader.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if module, ok := loader.state.modules[resolved]; ok {
+		return module, nil
+	}
+
+	for i, loading := range loader.state.loading {
+		if loading == resolved {
+			cycle := append([]string{}, loader.state.loading[i:]...)
+			cycle = append(cycle, resolved)
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	loader.state.loading = append(loader.state.loading, resolved)
+	defer func() {
+		loader.state.loading = loader.state.loading[:len(loader.state.loading)-1]
+	}()
+
+	module, err := loader.load(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	loader.state.modules[resolved] = module
+	return module, nil
+}
+
+func (loader *ModuleLoader) resolve(path string) (string, error) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{filepath.Join(loader.dir, path)}
+		for _, dir := range loader.searchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		return filepath.Abs(candidate)
+	}
+
+	return "", fmt.Errorf("module not found: %s", path)
+}
+
+func (loader *ModuleLoader) load(path string) (*object.Module, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.New(lexer.NewLexer(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("%s: %v", path, p.Errors()[0])
+	}
+
+	exports, err := loader.state.run(program, &ModuleLoader{
+		dir:        filepath.Dir(path),
+		searchPath: loader.searchPath,
+		state:      loader.state,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.Module{
+		Path:    path,
+		Exports: exports,
+	}, nil
+}
+
+func evalModule(
+	program *ast.Program,
+	importer object.Importer,
+	budget *object.Budget,
+) (map[string]object.Object, error) {
+	env := object.NewEnvironment()
+	env.SetImporter(importer)
+	if budget != nil {
+		env.SetBudget(budget)
+	}
+
+	if errs := resolver.Resolve(program, nil); len(errs) != 0 {
+		return nil, errs[0]
+	}
+
+	if result, ok := Eval(program, env).(*object.Error); ok {
+		if result.Kind != object.RuntimeError {
+			return nil, result
+		}
+		return nil, errors.New(result.Message)
+	}
+
+	return moduleExports(program, env), nil
+}
+
+func moduleExports(program *ast.Program, env *object.Environment) map[string]object.Object {
+	exports := map[string]object.Object{}
+
+	for _, stmt := range program.Statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok {
+			continue
+		}
+		for _, name := range letStatementNames(export.Statement) {
+			if value, ok := env.Get(name); ok {
+				exports[name] = value
+			}
+		}
+	}
+
+	return exports
+}
+
+func letStatementNames(stmt *ast.LetStatement) []string {
+	if stmt.Pattern == nil {
+		return []string{stmt.Name.Value}
+	}
+	return patternNames(stmt.Pattern)
+}
+
+// patternNames returns the names bound by a binding pattern.
+func patternNames(pattern ast.Pattern) []string {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		return []string{pattern.Name.Value}
+	case *ast.RestPattern:
+		return []string{pattern.Name.Value}
+	case *ast.DefaultPattern:
+		return patternNames(pattern.Target)
+	case *ast.ArrayPattern:
+		names := []string{}
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+		return names
+	case *ast.HashPattern:
+		names := []string{}
+		for _, pair := range pattern.Pairs {
+			names = append(names, patternNames(pair.Value)...)
+		}
+		return names
+	default:
+		return []string{}
+	}
+}
+
+func evalImportStatement(
+	stmt *ast.ImportStatement,
+	env *object.Environment,
+) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return newError("cannot import %s: no module loader", stmt.Path.Value)
+	}
+
+	module, err := importer.Import(stmt.Path.Value)
+	if err != nil {
+		var fatal *object.Error
+		if errors.As(err, &fatal) && fatal.Kind != object.RuntimeError {
+			return &object.Error{Kind: fatal.Kind, Message: fatal.Message}
+		}
+		return newError("%s", err)
+	}
+
+	define(stmt.Alias, module, env)
+	return nil
+}
+
+func evalMemberExpression(
+	exp *ast.MemberExpression,
+	env *object.Environment,
+) object.Object {
+	obj := Eval(exp.Object, env)
+	if isError(obj) {
+		return obj
+	}
+
+	switch obj := obj.(type) {
+	case *object.Module:
+		value, ok := obj.Exports[exp.Property.Value]
+		if !ok {
+			return newError("module %s has no export %s", obj.Path, exp.Property.Value)
+		}
+		return value
+	default:
+		return newError("cannot access member %s of %s", exp.Property.Value, obj.Type())
+	}
+}
@@ -0,0 +1,173 @@
+package evaluator
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// tailCall is a call in tail position that has not been made yet. It is
+// handed back to applyFunction, which makes the call in place of the
+// function that returned it, so that tail recursion runs in constant Go
+// stack. It never escapes applyFunction.
+type tailCall struct {
+	function object.Object
+	args     []object.Object
+	named    map[string]object.Object
+	pos      token.Position
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call" }
+
+// applyFunction calls fn, charging the call and the evaluation of its body
+// to budget, the budget of the caller.
+func applyFunction(
+	fn object.Object,
+	args []object.Object,
+	named map[string]object.Object,
+	budget *object.Budget,
+) object.Object {
+	// pos is the position of the tail call being made. The first call is
+	// stamped by the Eval of its call expression instead.
+	pos := token.Position{}
+
+	for {
+		result := applyOnce(fn, args, named, budget)
+		if err, ok := result.(*object.Error); ok && err.Pos == (token.Position{}) {
+			err.Pos = pos
+		}
+
+		call, ok := result.(*tailCall)
+		if !ok {
+			return result
+		}
+		fn, args, named, pos = call.function, call.args, call.named, call.pos
+	}
+}
+
+func applyOnce(
+	fn object.Object,
+	args []object.Object,
+	named map[string]object.Object,
+	budget *object.Budget,
+) object.Object {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		result := applyBuiltin(builtin, args, named)
+		if budget != nil {
+			if err := allocate(budget, result); err != nil {
+				return err
+			}
+		}
+		return result
+	}
+
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return newError("not a function: %s", fn.Type())
+	}
+
+	if budget != nil {
+		if err := budget.Enter(); err != nil {
+			return err
+		}
+		defer budget.Leave()
+
+		if err := budget.Allocate(environmentSize); err != nil {
+			return err
+		}
+	}
+
+	extendedEnv, err := extendFunctionEnv(function, args, named, budget)
+	if err != nil {
+		return err
+	}
+
+	evaluated := evalTail(function.Body, extendedEnv, true)
+	return unwrapReturnValue(evaluated)
+}
+
+// evalTail evaluates a node of a function body that the function returns
+// from, deferring the calls in tail position. Every return statement is in
+// tail position, and when valueIsTail is set, so is the value of node. A
+// try expression is never a tail position, since its handlers must see the
+// call finish.
+func evalTail(node ast.Node, env *object.Environment, valueIsTail bool) object.Object {
+	result := charge(node, env, func(node ast.Node, env *object.Environment) object.Object {
+		return evalTailNode(node, env, valueIsTail)
+	})
+	if err, ok := result.(*object.Error); ok && err.Pos == (token.Position{}) {
+		if tok, ok := nodeToken(node); ok {
+			err.Pos = tok.Pos
+		}
+	}
+	return result
+}
+
+func evalTailNode(node ast.Node, env *object.Environment, valueIsTail bool) object.Object {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		var result object.Object
+		for i, statement := range node.Statements {
+			result = evalTail(statement, env, valueIsTail && i == len(node.Statements)-1)
+			if result != nil {
+				if result.Type() == object.ReturnValueObject || result.Type() == object.ErrorObject {
+					return result
+				}
+			}
+		}
+		if result == nil {
+			return Null
+		}
+		return result
+	case *ast.ExpressionStatement:
+		return evalTail(node.Expression, env, valueIsTail)
+	case *ast.ReturnStatement:
+		val := evalTail(node.ReturnValue, env, true)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.IfExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return evalTail(node.Consequence, env, valueIsTail)
+		}
+		if node.Alternative != nil {
+			return evalTail(node.Alternative, env, valueIsTail)
+		}
+		return Null
+	case *ast.MatchExpression:
+		if valueIsTail {
+			return evalMatch(node, env, func(body ast.Node, env *object.Environment) object.Object {
+				return evalTail(body, env, true)
+			})
+		}
+	case *ast.CallExpression:
+		if valueIsTail {
+			return deferCall(node, env)
+		}
+	}
+
+	return eval(node, env)
+}
+
+func deferCall(node *ast.CallExpression, env *object.Environment) object.Object {
+	function := Eval(node.Function, env)
+	if isError(function) {
+		return function
+	}
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	named, err := evalNamedArguments(node.NamedArguments, env)
+	if err != nil {
+		return err
+	}
+
+	return &tailCall{function: function, args: args, named: named, pos: node.Token.Pos}
+}
@@ -0,0 +1,218 @@
+package format
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// atom is the precedence of the expressions that never need parentheses.
+const atom = parser.Member + 1
+
+var infixPrecedences = map[string]int{
+	"==": parser.Equals,
+	"!=": parser.Equals,
+	"<":  parser.LessGreater,
+	">":  parser.LessGreater,
+	"+":  parser.Sum,
+	"-":  parser.Sum,
+	"*":  parser.Product,
+	"/":  parser.Product,
+}
+
+func precedenceOf(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return infixPrecedences[exp.Operator]
+	case *ast.PrefixExpression:
+		return parser.Prefix
+	case *ast.CallExpression:
+		return parser.Call
+	case *ast.MemberExpression:
+		return parser.Member
+	default:
+		return atom
+	}
+}
+
+// expression prints exp, in parentheses if it binds less tightly than
+// precedence.
+func (p *printer) expression(exp ast.Expression, precedence int) {
+	if p.overflow {
+		return
+	}
+	if binding := precedenceOf(exp); binding < precedence {
+		p.write("(")
+		p.expression(exp, parser.Lowest)
+		p.write(")")
+		return
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.IntegerLiteral, *ast.BooleanLiteral, *ast.StringLiteral:
+		p.write(exp.String())
+	case *ast.PrefixExpression:
+		p.write(exp.Operator)
+		p.expression(exp.Right, parser.Prefix)
+	case *ast.InfixExpression:
+		binding := precedenceOf(exp)
+		p.expression(exp.Left, binding)
+		p.write(" " + exp.Operator + " ")
+		// Operators are left associative, so an operand on the right with
+		// the same precedence must be grouped.
+		p.expression(exp.Right, binding+1)
+	case *ast.CallExpression:
+		p.expression(exp.Function, parser.Call)
+		items := []item{}
+		for _, arg := range exp.Arguments {
+			arg := arg
+			items = append(items, item{arg.Pos(), func(p *printer) { p.expression(arg, parser.Lowest) }})
+		}
+		for _, arg := range exp.NamedArguments {
+			arg := arg
+			items = append(items, item{arg.Pos(), func(p *printer) { p.namedArgument(arg) }})
+		}
+		p.list("(", ")", items, exp.RightParen.Pos)
+	case *ast.MemberExpression:
+		p.expression(exp.Object, parser.Call)
+		p.write("." + exp.Property.Value)
+	case *ast.ArrayLiteral:
+		items := make([]item, len(exp.Elements))
+		for i, element := range exp.Elements {
+			element := element
+			items[i] = item{element.Pos(), func(p *printer) { p.expression(element, parser.Lowest) }}
+		}
+		p.list("[", "]", items, exp.RightBracket.Pos)
+	case *ast.HashLiteral:
+		items := make([]item, len(exp.Pairs))
+		for i, pair := range exp.Pairs {
+			pair := pair
+			items[i] = item{pair.Key.Pos(), func(p *printer) {
+				p.expression(pair.Key, parser.Lowest)
+				p.write(": ")
+				p.expression(pair.Value, parser.Lowest)
+			}}
+		}
+		p.list("{", "}", items, exp.RightBrace.Pos)
+	case *ast.FunctionLiteral:
+		p.write("fn")
+		p.patterns("(", ")", exp.Parameters, exp.Body.Token.Pos)
+		p.write(" ")
+		p.block(exp.Body)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(exp.Condition, parser.Lowest)
+		p.write(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative)
+		}
+	case *ast.MatchExpression:
+		p.write("match (")
+		p.expression(exp.Subject, parser.Lowest)
+		p.write(") {")
+		if len(exp.Arms) == 0 {
+			p.write("}")
+			return
+		}
+		p.indent++
+		for i, arm := range exp.Arms {
+			p.leadingComments(arm.Pos(), 0)
+			p.newline()
+			p.matchArm(arm)
+
+			boundary := exp.RightBrace.Pos
+			if i < len(exp.Arms)-1 {
+				p.write(",")
+				boundary = exp.Arms[i+1].Pos()
+			}
+			p.trailingComments(p.lastTokenBefore(boundary), boundary)
+		}
+		p.leadingComments(exp.RightBrace.Pos, 0)
+		p.indent--
+		p.newline()
+		p.write("}")
+	case *ast.TryExpression:
+		p.write("try ")
+		p.block(exp.Block)
+		if exp.Catch != nil {
+			p.write(" catch (")
+			p.pattern(exp.Parameter)
+			p.write(") ")
+			p.block(exp.Catch)
+		}
+		if exp.Finally != nil {
+			p.write(" finally ")
+			p.block(exp.Finally)
+		}
+	}
+}
+
+func (p *printer) namedArgument(arg *ast.NamedArgument) {
+	p.write(arg.Name.Value + ": ")
+	p.expression(arg.Value, parser.Lowest)
+}
+
+func (p *printer) matchArm(arm *ast.MatchArm) {
+	p.pattern(arm.Pattern)
+	if arm.Guard != nil {
+		p.write(" if ")
+		p.expression(arm.Guard, parser.Lowest)
+	}
+	p.write(" => ")
+	p.expression(arm.Body, parser.Lowest)
+}
+
+// item is an element of a list that starts at pos in the source.
+type item struct {
+	pos   token.Position
+	print func(*printer)
+}
+
+// list prints items between open and close, the closing token being at
+// closePos in the source. They go on the current line if its first line
+// fits, and one per line otherwise. A list with a comment between its
+// elements is printed one per line too, with each comment before or after
+// the element it is next to.
+func (p *printer) list(open, close string, items []item, closePos token.Position) {
+	if len(items) == 0 && !p.commentBefore(closePos) {
+		p.write(open + close)
+		return
+	}
+
+	flat := p.fork()
+	flat.write(open)
+	for i, item := range items {
+		if i > 0 {
+			flat.write(", ")
+		}
+		item.print(flat)
+	}
+	flat.write(close)
+	if !flat.overflow && !flat.commentBefore(closePos) {
+		p.join(flat)
+		return
+	}
+
+	p.write(open)
+	p.indent++
+	for i, item := range items {
+		p.leadingComments(item.pos, 0)
+		p.newline()
+		item.print(p)
+
+		boundary := closePos
+		if i < len(items)-1 {
+			p.write(",")
+			boundary = items[i+1].pos
+		}
+		p.trailingComments(p.lastTokenBefore(boundary), boundary)
+	}
+	p.leadingComments(closePos, 0)
+	p.indent--
+	p.newline()
+	p.write(close)
+}
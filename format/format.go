@@ -0,0 +1,345 @@
+// Package format prints monkey programs in the canonical style of monkey fmt.
+//
+// Blocks are indented by four spaces and always span several lines. Call
+// arguments, parameters, and array, hash and pattern elements are printed on
+// one line when it fits in 80 columns, and one per line otherwise.
+// Parentheses are only kept where precedence needs them, and statements end
+// with a semicolon except for the value of a block and expressions that end
+// with a closing brace.
+//
+// Comments are kept on the line they were on when it starts or ends a
+// statement, an element of a list or an arm of a match expression. A list
+// holding such a comment is printed one element per line. Any other comment
+// in the middle of a statement is moved after it.
+package format
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+const (
+	width       = 80
+	indentation = "    "
+)
+
+// ParseError lists the syntax errors that kept a source from being
+// formatted.
+type ParseError struct {
+	Errors []error
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Source formats the program in src.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.NewLexer(string(src))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.Errors()}
+	}
+
+	printer := &printer{comments: l.Comments(), tokens: tokenize(src)}
+	printer.program(program)
+	return []byte(printer.out.String()), nil
+}
+
+// Node prints node in canonical form. Since the AST does not keep them, the
+// comments of the source node was parsed from are lost.
+func Node(node ast.Node) string {
+	p := &printer{}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		p.program(node)
+	case *ast.BlockStatement:
+		p.block(node)
+	case ast.Statement:
+		p.statement(node)
+	case ast.Expression:
+		p.expression(node, parser.Lowest)
+	case ast.Pattern:
+		p.pattern(node)
+	case *ast.NamedArgument:
+		p.namedArgument(node)
+	case *ast.MatchArm:
+		p.matchArm(node)
+	}
+
+	return p.out.String()
+}
+
+// tokenize returns the tokens of src, up to and including EOF.
+func tokenize(src []byte) []token.Token {
+	l := lexer.NewLexer(string(src))
+
+	tokens := []token.Token{}
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+type printer struct {
+	out    strings.Builder
+	indent int
+	column int
+
+	// comments are the comments not printed yet, and tokens the tokens of
+	// the source, used to find where its statements end.
+	comments []token.Token
+	tokens   []token.Token
+
+	// limit is set on a printer trying to fit its output on the rest of the
+	// line. It stops printing, and sets overflow, as soon as the first line
+	// of its output is too long.
+	limit    bool
+	overflow bool
+	wrapped  bool
+}
+
+func (p *printer) write(s string) {
+	if p.overflow {
+		return
+	}
+
+	first := s
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		first = s[:i]
+	}
+	if p.limit && !p.wrapped && p.column+utf8.RuneCountInString(first) > width {
+		p.overflow = true
+		return
+	}
+
+	p.out.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.wrapped = true
+		p.column = utf8.RuneCountInString(s[i+1:])
+	} else {
+		p.column += utf8.RuneCountInString(s)
+	}
+}
+
+// newline starts a new line at the current indentation, unless nothing has
+// been printed yet.
+func (p *printer) newline() {
+	if p.out.Len() == 0 {
+		return
+	}
+	p.write("\n" + strings.Repeat(indentation, p.indent))
+}
+
+// fork returns a printer that continues the output of p on the current
+// line, for trying a layout.
+func (p *printer) fork() *printer {
+	return &printer{
+		indent:   p.indent,
+		column:   p.column,
+		comments: p.comments,
+		tokens:   p.tokens,
+		limit:    true,
+	}
+}
+
+// join adds the output of a fork to p.
+func (p *printer) join(fork *printer) {
+	p.write(fork.out.String())
+	p.comments = fork.comments
+}
+
+func (p *printer) program(program *ast.Program) {
+	end := token.Position{}
+	if len(p.tokens) > 0 {
+		end = p.tokens[len(p.tokens)-1].Pos
+	}
+
+	p.statements(program.Statements, end, true)
+	if p.out.Len() > 0 {
+		p.write("\n")
+	}
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && !p.commentBefore(block.RightBrace.Pos) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.statements(block.Statements, block.RightBrace.Pos, false)
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// statements prints the statements of a program or block, which ends at
+// end. The last statement of a block is its value and is printed without a
+// semicolon.
+func (p *printer) statements(statements []ast.Statement, end token.Position, top bool) {
+	// last is the line the previous statement or comment ended on in the
+	// source, to keep the blank lines between them.
+	last := 0
+
+	for i, stmt := range statements {
+		start := stmt.Pos()
+		last = p.leadingComments(start, last)
+		p.blankLine(last, start.Line)
+		p.newline()
+
+		boundary := end
+		var next ast.Statement
+		if i+1 < len(statements) {
+			next = statements[i+1]
+			boundary = next.Pos()
+		}
+
+		p.statement(stmt)
+		if _, ok := stmt.(*ast.ExpressionStatement); ok && p.needsSemicolon(next, top) {
+			p.write(";")
+		}
+
+		stmtEnd := p.lastTokenBefore(boundary)
+		p.trailingComments(stmtEnd, boundary)
+		last = stmtEnd.Line
+	}
+
+	p.leadingComments(end, last)
+}
+
+// needsSemicolon reports whether the expression statement just printed
+// must end with a semicolon, given the statement following it in a program
+// or block. An expression ending with a brace needs none to end it, unless
+// next starts with a token that would continue it.
+func (p *printer) needsSemicolon(next ast.Statement, top bool) bool {
+	if !strings.HasSuffix(p.out.String(), "}") {
+		return next != nil || top
+	}
+	if next == nil {
+		return false
+	}
+	following := Node(next)
+	return strings.HasPrefix(following, "(") || strings.HasPrefix(following, "-")
+}
+
+func (p *printer) blankLine(last, line int) {
+	if last > 0 && line > last+1 {
+		p.write("\n")
+	}
+}
+
+func (p *printer) commentBefore(pos token.Position) bool {
+	return len(p.comments) > 0 && before(p.comments[0].Pos, pos)
+}
+
+// leadingComments prints the comments before pos on lines of their own, and
+// returns the line of the last one.
+func (p *printer) leadingComments(pos token.Position, last int) int {
+	for p.commentBefore(pos) {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.blankLine(last, comment.Pos.Line)
+		p.newline()
+		p.write(comment.Literal)
+		last = comment.Pos.Line
+	}
+	return last
+}
+
+// trailingComments prints the comments left inside a statement, list
+// element or match arm whose last token is at end, and the comment following
+// it on the same line before boundary, where the next one or the enclosing
+// block or list starts.
+func (p *printer) trailingComments(end, boundary token.Position) {
+	inside := false
+	for p.commentBefore(end) {
+		p.newline()
+		p.write(p.comments[0].Literal)
+		p.comments = p.comments[1:]
+		inside = true
+	}
+
+	if !p.commentBefore(boundary) || p.comments[0].Pos.Line != end.Line {
+		return
+	}
+	if inside {
+		p.newline()
+	} else {
+		p.write(" ")
+	}
+	p.write(p.comments[0].Literal)
+	p.comments = p.comments[1:]
+}
+
+// lastTokenBefore returns the position of the last token of the source
+// before pos.
+func (p *printer) lastTokenBefore(pos token.Position) token.Position {
+	i := sort.Search(len(p.tokens), func(i int) bool {
+		return !before(p.tokens[i].Pos, pos)
+	})
+	if i == 0 {
+		return token.Position{}
+	}
+	return p.tokens[i-1].Pos
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// statement prints stmt. The semicolon ending an expression statement is
+// left to the caller, which knows what follows.
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		if stmt.Pattern != nil {
+			p.pattern(stmt.Pattern)
+		} else {
+			p.write(stmt.Name.Value)
+		}
+		p.write(" = ")
+		p.expression(stmt.Value, parser.Lowest)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue, parser.Lowest)
+		}
+		p.write(";")
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(stmt.Value, parser.Lowest)
+		p.write(";")
+	case *ast.ImportStatement:
+		p.write("import ")
+		p.expression(stmt.Path, parser.Lowest)
+		p.write(" as " + stmt.Alias.Value + ";")
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.statement(stmt.Statement)
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.Lowest)
+	case *ast.BlockStatement:
+		p.block(stmt)
+	}
+}
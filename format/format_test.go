@@ -0,0 +1,88 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/format"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+var sourceTests = []struct {
+	input    string
+	expected string
+}{
+	{"", ""},
+	{"let   x=1", "let x = 1;\n"},
+	{"puts(x)", "puts(x);\n"},
+	{"let x = (1 + 2) * 3 - (4 - 5) - -(a + b);", "let x = (1 + 2) * 3 - (4 - 5) - -(a + b);\n"},
+	{"let x = ((a * b) + (c / d)) == (e < f);", "let x = a * b + c / d == e < f;\n"},
+	{"(fn(x) { x })(1).y", "fn(x) {\n    x\n}(1).y;\n"},
+	{"let f = fn(){};", "let f = fn() {};\n"},
+	{"let add = fn(a,b){return a+b;};", "let add = fn(a, b) {\n    return a + b;\n};\n"},
+	{"if (x > 1) { puts(\"big\"); 1 } else { 2 }",
+		"if (x > 1) {\n    puts(\"big\");\n    1\n} else {\n    2\n}\n"},
+	{"if (x) { 1 }; -1", "if (x) {\n    1\n};\n-1;\n"},
+	{"if (x) { 1 } let y = 2;", "if (x) {\n    1\n}\nlet y = 2;\n"},
+	{"let y = add(first: 1, second: [1, 2], third: {\"a\": 1});",
+		"let y = add(first: 1, second: [1, 2], third: {\"a\": 1});\n"},
+	{"let long = someFunction(argumentNumberOne, argumentNumberTwo, argumentNumberThree, four);",
+		"let long = someFunction(\n    argumentNumberOne,\n    argumentNumberTwo,\n    argumentNumberThree,\n    four\n);\n"},
+	{"let f = fn([a, ...rest], {name}, {\"k\": v}, {\"l\": l}, d = 2) { a }",
+		"let f = fn([a, ...rest], {name}, {\"k\": v}, {l}, d = 2) {\n    a\n};\n"},
+	{"match (a) { 1 if y => \"one\", [_, b] => b, -1 => {\"x\": 1} }",
+		"match (a) {\n    1 if y => \"one\",\n    [_, b] => b,\n    -1 => {\"x\": 1}\n}\n"},
+	{"try { throw y } catch (e) { e } finally { puts(1) }",
+		"try {\n    throw y;\n} catch (e) {\n    e\n} finally {\n    puts(1)\n}\n"},
+	{"import \"lib/math.mk\" as   math; export let pi = 3;",
+		"import \"lib/math.mk\" as math;\nexport let pi = 3;\n"},
+	{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+	{"// header\n\nlet a = 1; // one\n// before b\nlet b = fn() {\n  // inside\n  b // value\n  // end\n};\n// last",
+		"// header\n\nlet a = 1; // one\n// before b\nlet b = fn() {\n    // inside\n    b // value\n    // end\n};\n// last\n"},
+	{"let f = fn() { 1 }; // after the block", "let f = fn() {\n    1\n}; // after the block\n"},
+	{"let f = fn() { // empty\n};", "let f = fn() {\n    // empty\n};\n"},
+	{"let x = f(1, // one\n2); // call", "let x = f(\n    1, // one\n    2\n); // call\n"},
+	{"let h = {\n  // first\n  \"a\": 1,\n  \"b\": 2 // second\n};",
+		"let h = {\n    // first\n    \"a\": 1,\n    \"b\": 2 // second\n};\n"},
+	{"let f = fn(a, // the a\n b) { a + b };", "let f = fn(\n    a, // the a\n    b\n) {\n    a + b\n};\n"},
+	{"let [a, // first\n b] = x;", "let [\n    a, // first\n    b\n] = x;\n"},
+	{"let a = [\n // none\n];", "let a = [\n    // none\n];\n"},
+	{"match (x) {\n  // zero\n  0 => 1, // one\n  _ => 2\n  // end\n}",
+		"match (x) {\n    // zero\n    0 => 1, // one\n    _ => 2\n    // end\n}\n"},
+	{"map(xs, fn(x) { // double\n x * 2 });", "map(xs, fn(x) {\n    // double\n    x * 2\n});\n"},
+	{"let x = 1 + // plus\n 2;", "let x = 1 + 2;\n// plus\n"},
+}
+
+func TestSource(t *testing.T) {
+	for _, tt := range sourceTests {
+		formatted, err := format.Source([]byte(tt.input))
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, string(formatted), tt.input)
+	}
+}
+
+func TestSourceKeepsProgram(t *testing.T) {
+	parse := func(input string) string {
+		p := parser.New(lexer.NewLexer(input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), input)
+		return program.String()
+	}
+
+	for _, tt := range sourceTests {
+		formatted, err := format.Source([]byte(tt.input))
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, parse(tt.input), parse(string(formatted)), tt.input)
+
+		again, err := format.Source(formatted)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, string(formatted), string(again), tt.input)
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	_, err := format.Source([]byte("let = 1;"))
+	assert.EqualError(t, err,
+		"expected next token to be Ident, got Assign instead\nno prefix parse function for Assign found")
+}
@@ -0,0 +1,56 @@
+package format
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value, parser.Lowest)
+	case *ast.IdentifierPattern:
+		p.write(pattern.Name.Value)
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.RestPattern:
+		p.write("..." + pattern.Name.Value)
+	case *ast.DefaultPattern:
+		p.pattern(pattern.Target)
+		p.write(" = ")
+		p.expression(pattern.Default, parser.Lowest)
+	case *ast.ArrayPattern:
+		p.patterns("[", "]", pattern.Elements, pattern.RightBracket.Pos)
+	case *ast.HashPattern:
+		items := make([]item, len(pattern.Pairs))
+		for i, pair := range pattern.Pairs {
+			pair := pair
+			items[i] = item{pair.Key.Pos(), func(p *printer) { p.hashPatternPair(pair) }}
+		}
+		p.list("{", "}", items, pattern.RightBrace.Pos)
+	}
+}
+
+func (p *printer) patterns(open, close string, patterns []ast.Pattern, closePos token.Position) {
+	items := make([]item, len(patterns))
+	for i, pattern := range patterns {
+		pattern := pattern
+		items[i] = item{pattern.Pos(), func(p *printer) { p.pattern(pattern) }}
+	}
+	p.list(open, close, items, closePos)
+}
+
+// hashPatternPair prints {"name": name} as its shorthand {name}.
+func (p *printer) hashPatternPair(pair ast.HashPatternPair) {
+	key, isString := pair.Key.(*ast.StringLiteral)
+	value, isIdentifier := pair.Value.(*ast.IdentifierPattern)
+	if isString && isIdentifier && key.Value == value.Name.Value {
+		p.write(value.Name.Value)
+		return
+	}
+
+	p.expression(pair.Key, parser.Lowest)
+	p.write(": ")
+	p.pattern(pair.Value)
+}
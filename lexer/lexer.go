@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"sort"
 	"unicode"
 
 	"github.com/computerphilosopher/monkey-interpreter/token"
@@ -12,17 +13,35 @@ type Lexer struct {
 	ch           rune
 	position     int
 	readPosition int
+	lineStarts   []int
+	comments     []token.Token
 }
 
 func NewLexer(input string) *Lexer {
 	ret := &Lexer{
-		input: []rune(input + "\x00"),
+		input:      []rune(input + "\x00"),
+		lineStarts: []int{0},
+	}
+	for i, ch := range ret.input {
+		if ch == '\n' {
+			ret.lineStarts = append(ret.lineStarts, i+1)
+		}
 	}
 	ret.stepForward()
 
 	return ret
 }
 
+func (lexer *Lexer) positionOf(offset int) token.Position {
+	line := sort.Search(len(lexer.lineStarts), func(i int) bool {
+		return lexer.lineStarts[i] > offset
+	})
+	return token.Position{
+		Line:   line,
+		Column: offset - lexer.lineStarts[line-1] + 1,
+	}
+}
+
 func (lexer *Lexer) stepForward() {
 
 	lexer.position = lexer.readPosition
@@ -102,9 +121,37 @@ func (lexer *Lexer) skipWhitespace() {
 	isWhiteSpace := func(ch rune) bool {
 		return ch == ' ' || ch == '\n' || ch == '\t'
 	}
-	for isWhiteSpace(lexer.ch) {
+	for {
+		switch {
+		case isWhiteSpace(lexer.ch):
+			lexer.stepForward()
+		case lexer.ch == '/' && lexer.peekChar() == '/':
+			lexer.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment skips a comment running from // to the end of the line and
+// records it for Comments.
+func (lexer *Lexer) readComment() {
+	begin := lexer.position
+	for lexer.ch != '\n' && lexer.ch != '\x00' {
 		lexer.stepForward()
 	}
+
+	lexer.comments = append(lexer.comments, token.Token{
+		Type:    token.Comment,
+		Literal: string(lexer.input[begin:lexer.position]),
+		Pos:     lexer.positionOf(begin),
+	})
+}
+
+// Comments returns the comments skipped so far, in source order. The
+// parser never sees them.
+func (lexer *Lexer) Comments() []token.Token {
+	return lexer.comments
 }
 
 func (lexer *Lexer) peekChar() rune {
@@ -130,21 +177,73 @@ func (lexer *Lexer) handleSingleToken() token.Token {
 			Literal: "==",
 		}
 	}
+	if lexer.ch == '.' && lexer.peekChar() == '.' {
+		return lexer.readEllipsis()
+	}
+	if lexer.ch == '=' && lexer.peekChar() == '>' {
+		lexer.stepForward()
+		return token.Token{
+			Type:    token.FatArrow,
+			Literal: "=>",
+		}
+	}
 	return token.Token{
 		Type:    token.SingleToken[lexer.ch],
 		Literal: runeToString(lexer.ch),
 	}
 }
 
+func (lexer *Lexer) readString() token.Token {
+	begin := lexer.position + 1
+	for {
+		lexer.stepForward()
+		if lexer.ch == '"' {
+			break
+		}
+		if lexer.ch == '\x00' {
+			return token.Token{
+				Type:    token.Illegal,
+				Literal: string(lexer.input[begin-1 : lexer.position]),
+			}
+		}
+	}
+
+	return token.Token{
+		Type:    token.String,
+		Literal: string(lexer.input[begin:lexer.position]),
+	}
+}
+
+func (lexer *Lexer) readEllipsis() token.Token {
+	lexer.stepForward()
+	if lexer.peekChar() != '.' {
+		return token.Token{
+			Type:    token.Illegal,
+			Literal: "..",
+		}
+	}
+	lexer.stepForward()
+
+	return token.Token{
+		Type:    token.Ellipsis,
+		Literal: "...",
+	}
+}
+
 func (lexer *Lexer) NextToken() token.Token {
 	lexer.skipWhitespace()
+	start := lexer.position
 	ret := func() token.Token {
+		if lexer.ch == '"' {
+			return lexer.readString()
+		}
 		if _, isSingletoken := token.SingleToken[lexer.ch]; isSingletoken {
 			return lexer.handleSingleToken()
 		}
 		return lexer.readStringToken(keepGoingFunc(lexer.ch),
 			tokenTypeFunc(lexer.ch))
 	}()
+	ret.Pos = lexer.positionOf(start)
 
 	lexer.stepForward()
 	return ret
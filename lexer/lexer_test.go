@@ -8,7 +8,13 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func Helper(t *testing.T, input string, expectedTokens []token.Token) {
+// expectedToken is a token as the tests expect it, without its position.
+type expectedToken struct {
+	Type    token.TokenType
+	Literal string
+}
+
+func Helper(t *testing.T, input string, expectedTokens []expectedToken) {
 
 	lexer := lexer.NewLexer(input)
 	for _, expected := range expectedTokens {
@@ -21,7 +27,7 @@ func Helper(t *testing.T, input string, expectedTokens []token.Token) {
 func TestSingleToken(t *testing.T) {
 
 	input := "=!!=+==-*/(){},;"
-	expected := []token.Token{
+	expected := []expectedToken{
 		{token.Assign, "="},
 		{token.Bang, "!"},
 		{token.NotEqual, "!="},
@@ -44,7 +50,7 @@ func TestSingleToken(t *testing.T) {
 
 func TestLetStatement(t *testing.T) {
 	varDeclare := "let five = 5;"
-	expected := []token.Token{
+	expected := []expectedToken{
 		{token.Let, "let"},
 		{token.Ident, "five"},
 		{token.Assign, "="},
@@ -59,7 +65,7 @@ func TestLetStatement(t *testing.T) {
 		"return x + y;\n" +
 		"};"
 
-	expected = []token.Token{
+	expected = []expectedToken{
 		{token.Let, "let"},
 		{token.Ident, "add"},
 		{token.Assign, "="},
@@ -89,7 +95,7 @@ func TestLetStatement(t *testing.T) {
 		"else { return false\n" +
 		"}"
 
-	expected = []token.Token{
+	expected = []expectedToken{
 		{token.If, "if"},
 		{token.LeftParen, "("},
 		{token.Int, "5"},
@@ -121,3 +127,83 @@ func TestLetStatement(t *testing.T) {
 
 	Helper(t, conditionalFunc, expected)
 }
+
+func TestMatchExpression(t *testing.T) {
+	input := `match (x) { [a, "b"] => {"k": a}, _ => "" }`
+	expected := []expectedToken{
+		{token.Match, "match"},
+		{token.LeftParen, "("},
+		{token.Ident, "x"},
+		{token.RightParen, ")"},
+		{token.LeftBrace, "{"},
+		{token.LeftBracket, "["},
+		{token.Ident, "a"},
+		{token.Comma, ","},
+		{token.String, "b"},
+		{token.RightBracket, "]"},
+		{token.FatArrow, "=>"},
+		{token.LeftBrace, "{"},
+		{token.String, "k"},
+		{token.Colon, ":"},
+		{token.Ident, "a"},
+		{token.RightBrace, "}"},
+		{token.Comma, ","},
+		{token.Ident, "_"},
+		{token.FatArrow, "=>"},
+		{token.String, ""},
+		{token.RightBrace, "}"},
+		{token.EOF, ""},
+	}
+
+	Helper(t, input, expected)
+}
+
+func TestTokenPosition(t *testing.T) {
+	input := "let x = 5;\n  x + \"ab\";\n"
+	expected := []token.Position{
+		{Line: 1, Column: 1},
+		{Line: 1, Column: 5},
+		{Line: 1, Column: 7},
+		{Line: 1, Column: 9},
+		{Line: 1, Column: 10},
+		{Line: 2, Column: 3},
+		{Line: 2, Column: 5},
+		{Line: 2, Column: 7},
+		{Line: 2, Column: 11},
+		{Line: 3, Column: 1},
+	}
+
+	l := lexer.NewLexer(input)
+	for _, pos := range expected {
+		tok := l.NextToken()
+		assert.Equal(t, pos, tok.Pos, tok.Literal)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "// leading\nlet x = 5; // trailing\nx / y //last"
+	expected := []expectedToken{
+		{token.Let, "let"},
+		{token.Ident, "x"},
+		{token.Assign, "="},
+		{token.Int, "5"},
+		{token.Semicolon, ";"},
+		{token.Ident, "x"},
+		{token.Slash, "/"},
+		{token.Ident, "y"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.NewLexer(input)
+	for _, expected := range expected {
+		tok := l.NextToken()
+		assert.Equal(t, expected.Type, tok.Type)
+		assert.Equal(t, expected.Literal, tok.Literal)
+	}
+
+	assert.Equal(t, []token.Token{
+		{Type: token.Comment, Literal: "// leading", Pos: token.Position{Line: 1, Column: 1}},
+		{Type: token.Comment, Literal: "// trailing", Pos: token.Position{Line: 2, Column: 12}},
+		{Type: token.Comment, Literal: "//last", Pos: token.Position{Line: 3, Column: 7}},
+	}, l.Comments())
+}
@@ -0,0 +1,388 @@
+// Package lint reports code in a monkey program that is valid but likely a
+// mistake: names that are not defined, variables and parameters that are
+// never used, names that shadow a variable of an enclosing scope,
+// statements after a return or a throw, conditions that are literals,
+// functions compared with == or !=, and calls to a known function with the
+// wrong number of arguments.
+//
+// Names are visible as the resolver sees them. A name is undefined when it
+// is not bound in the program; the globals a host or the REPL defines are
+// not known here. A variable or parameter whose name
+// starts with an underscore is not reported as unused, nor is a variable
+// that is exported.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// The checks a Diagnostic can come from.
+const (
+	Undefined          = "undefined"
+	UnusedVariable     = "unused-variable"
+	UnusedParameter    = "unused-parameter"
+	Shadow             = "shadow"
+	Unreachable        = "unreachable"
+	ConstantCondition  = "constant-condition"
+	FunctionComparison = "function-comparison"
+	Arity              = "arity"
+)
+
+// Diagnostic is a likely mistake found between Pos and End.
+type Diagnostic struct {
+	Pos     token.Position `json:"pos"`
+	End     token.Position `json:"end"`
+	Check   string         `json:"check"`
+	Message string         `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Check)
+}
+
+// Lint returns the diagnostics for program, in source order.
+func Lint(program *ast.Program) []Diagnostic {
+	l := &linter{}
+	global := newScope(nil)
+
+	l.statements(program.Statements, global)
+	for len(l.pending) > 0 {
+		function := l.pending[0]
+		l.pending = l.pending[1:]
+		function()
+	}
+
+	for _, v := range l.variables {
+		if v.used || strings.HasPrefix(v.name.Value, "_") {
+			continue
+		}
+		switch v.kind {
+		case letVariable:
+			l.report(v.name, UnusedVariable, "%s declared and not used", v.name.Value)
+		case parameter:
+			l.report(v.name, UnusedParameter, "parameter %s is not used", v.name.Value)
+		}
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i].Pos, l.diagnostics[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+type kind int
+
+const (
+	letVariable kind = iota
+	parameter
+	// binding is a name bound by a match arm, a catch clause or an import.
+	binding
+)
+
+type variable struct {
+	name *ast.Identifier
+	kind kind
+	// function is the function literal a let bound the variable to, whose
+	// parameters are known when it is called.
+	function *ast.FunctionLiteral
+	used     bool
+}
+
+type scope struct {
+	variables map[string]*variable
+	outer     *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{variables: map[string]*variable{}, outer: outer}
+}
+
+func (s *scope) lookup(name string) *variable {
+	for ; s != nil; s = s.outer {
+		if v, ok := s.variables[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+type linter struct {
+	// pending queues function bodies until the statements around them are
+	// linted, since a function can use a name declared after it.
+	pending     []func()
+	variables   []*variable
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(node ast.Node, check string, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Pos:     node.Pos(),
+		End:     node.End(),
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// declare binds name in s. A let of a name already bound in s reuses its
+// variable at run time, but is a new variable here so that a value that is
+// overwritten before it is read is reported.
+func (l *linter) declare(name *ast.Identifier, k kind, s *scope) *variable {
+	if _, ok := s.variables[name.Value]; !ok {
+		if outer := s.outer.lookup(name.Value); outer != nil {
+			l.report(name, Shadow, "%s shadows the variable declared at %s", name.Value, outer.name.Pos())
+		}
+	}
+
+	v := &variable{name: name, kind: k}
+	s.variables[name.Value] = v
+	l.variables = append(l.variables, v)
+	return v
+}
+
+func (l *linter) statements(stmts []ast.Statement, s *scope) {
+	for i, stmt := range stmts {
+		l.statement(stmt, s)
+
+		if i == len(stmts)-1 {
+			continue
+		}
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.ThrowStatement:
+			l.diagnostics = append(l.diagnostics, Diagnostic{
+				Pos:     stmts[i+1].Pos(),
+				End:     stmts[len(stmts)-1].End(),
+				Check:   Unreachable,
+				Message: fmt.Sprintf("unreachable code after %s", stmt.TokenLiteral()),
+			})
+			// The statements that follow are still linted, but only the
+			// first one that cannot run is reported.
+			for _, stmt := range stmts[i+1:] {
+				l.statement(stmt, s)
+			}
+			return
+		}
+	}
+}
+
+func (l *linter) statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		l.let(stmt, s)
+	case *ast.ReturnStatement:
+		if stmt.ReturnValue != nil {
+			l.expression(stmt.ReturnValue, s)
+		}
+	case *ast.ExpressionStatement:
+		if stmt.Expression != nil {
+			l.expression(stmt.Expression, s)
+		}
+	case *ast.BlockStatement:
+		l.statements(stmt.Statements, s)
+	case *ast.ImportStatement:
+		l.declare(stmt.Alias, binding, s)
+	case *ast.ExportStatement:
+		for _, v := range l.let(stmt.Statement, s) {
+			v.used = true
+		}
+	case *ast.ThrowStatement:
+		l.expression(stmt.Value, s)
+	}
+}
+
+// let lints a let statement and returns the variables it declares.
+func (l *linter) let(stmt *ast.LetStatement, s *scope) []*variable {
+	l.expression(stmt.Value, s)
+	if stmt.Pattern != nil {
+		return l.pattern(stmt.Pattern, letVariable, s)
+	}
+
+	v := l.declare(stmt.Name, letVariable, s)
+	v.function, _ = stmt.Value.(*ast.FunctionLiteral)
+	return []*variable{v}
+}
+
+func (l *linter) expression(exp ast.Expression, s *scope) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		if v := s.lookup(exp.Value); v != nil {
+			v.used = true
+		} else {
+			l.report(exp, Undefined, "undefined: %s", exp.Value)
+		}
+	case *ast.PrefixExpression:
+		l.expression(exp.Right, s)
+	case *ast.InfixExpression:
+		l.expression(exp.Left, s)
+		l.expression(exp.Right, s)
+		if (exp.Operator == "==" || exp.Operator == "!=") &&
+			(isFunction(exp.Left, s) || isFunction(exp.Right, s)) {
+			l.report(exp, FunctionComparison,
+				"functions compared with %s are only equal when they are the same function", exp.Operator)
+		}
+	case *ast.IfExpression:
+		l.condition(exp.Condition)
+		l.expression(exp.Condition, s)
+		l.statements(exp.Consequence.Statements, s)
+		if exp.Alternative != nil {
+			l.statements(exp.Alternative.Statements, s)
+		}
+	case *ast.FunctionLiteral:
+		l.pending = append(l.pending, func() { l.function(exp, s) })
+	case *ast.CallExpression:
+		l.expression(exp.Function, s)
+		for _, arg := range exp.Arguments {
+			l.expression(arg, s)
+		}
+		for _, arg := range exp.NamedArguments {
+			l.expression(arg.Value, s)
+		}
+		l.call(exp, s)
+	case *ast.ArrayLiteral:
+		for _, element := range exp.Elements {
+			l.expression(element, s)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range exp.Pairs {
+			l.expression(pair.Key, s)
+			l.expression(pair.Value, s)
+		}
+	case *ast.MatchExpression:
+		l.expression(exp.Subject, s)
+		for _, arm := range exp.Arms {
+			armScope := newScope(s)
+			l.pattern(arm.Pattern, binding, armScope)
+			if arm.Guard != nil {
+				l.condition(arm.Guard)
+				l.expression(arm.Guard, armScope)
+			}
+			l.expression(arm.Body, armScope)
+		}
+	case *ast.MemberExpression:
+		l.expression(exp.Object, s)
+	case *ast.TryExpression:
+		l.statements(exp.Block.Statements, s)
+		if exp.Catch != nil {
+			catchScope := newScope(s)
+			l.pattern(exp.Parameter, binding, catchScope)
+			l.statements(exp.Catch.Statements, catchScope)
+		}
+		if exp.Finally != nil {
+			l.statements(exp.Finally.Statements, s)
+		}
+	}
+}
+
+// condition reports a condition that is a literal, and so always takes the
+// same branch.
+func (l *linter) condition(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.BooleanLiteral:
+		l.report(exp, ConstantCondition, "condition is always %t", exp.Value)
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		l.report(exp, ConstantCondition, "condition is always true")
+	}
+}
+
+// function lints the parameters and the body of a function whose
+// environment encloses s.
+func (l *linter) function(function *ast.FunctionLiteral, s *scope) {
+	fnScope := newScope(s)
+	for _, param := range function.Parameters {
+		l.pattern(param, parameter, fnScope)
+	}
+	l.statements(function.Body.Statements, fnScope)
+}
+
+// pattern lints the values in pattern and declares its names in s as
+// variables of kind k.
+func (l *linter) pattern(pattern ast.Pattern, k kind, s *scope) []*variable {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		return []*variable{l.declare(pattern.Name, k, s)}
+	case *ast.RestPattern:
+		return []*variable{l.declare(pattern.Name, k, s)}
+	case *ast.LiteralPattern:
+		l.expression(pattern.Value, s)
+	case *ast.DefaultPattern:
+		l.expression(pattern.Default, s)
+		return l.pattern(pattern.Target, k, s)
+	case *ast.ArrayPattern:
+		variables := []*variable{}
+		for _, element := range pattern.Elements {
+			variables = append(variables, l.pattern(element, k, s)...)
+		}
+		return variables
+	case *ast.HashPattern:
+		variables := []*variable{}
+		for _, pair := range pattern.Pairs {
+			l.expression(pair.Key, s)
+			variables = append(variables, l.pattern(pair.Value, k, s)...)
+		}
+		return variables
+	}
+	return nil
+}
+
+// call reports a call to a known function that will fail for the number of
+// arguments it is given, counted as the evaluator does.
+func (l *linter) call(call *ast.CallExpression, s *scope) {
+	name := "function"
+	var function *ast.FunctionLiteral
+
+	switch callee := call.Function.(type) {
+	case *ast.FunctionLiteral:
+		function = callee
+	case *ast.Identifier:
+		name = callee.Value
+		if v := s.lookup(callee.Value); v != nil {
+			function = v.function
+		}
+	}
+	if function == nil {
+		return
+	}
+
+	fixed, rest := ast.SplitRest(function.Parameters)
+	variadic := rest != nil
+	required := 0
+	for _, param := range fixed {
+		if _, ok := param.(*ast.DefaultPattern); !ok {
+			required++
+		}
+	}
+
+	given := len(call.Arguments) + len(call.NamedArguments)
+	if given >= required && (variadic || len(call.Arguments) <= len(fixed)) {
+		return
+	}
+	switch {
+	case variadic:
+		l.report(call, Arity, "wrong number of arguments to %s: want at least %d, got=%d", name, required, given)
+	case required == len(fixed):
+		l.report(call, Arity, "wrong number of arguments to %s: want=%d, got=%d", name, required, given)
+	default:
+		l.report(call, Arity, "wrong number of arguments to %s: want %d to %d, got=%d",
+			name, required, len(fixed), given)
+	}
+}
+
+// isFunction reports whether exp is known to evaluate to a function.
+func isFunction(exp ast.Expression, s *scope) bool {
+	switch exp := exp.(type) {
+	case *ast.FunctionLiteral:
+		return true
+	case *ast.Identifier:
+		if v := s.lookup(exp.Value); v != nil {
+			return v.function != nil
+		}
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = 1; let f = fn(x) { x + a }; f(2)", []string{}},
+		{"let a = 1;", []string{"1:5: a declared and not used (unused-variable)"}},
+		{"x; let x = 1; let f = fn() { x + y }; f()", []string{"1:1: undefined: x (undefined)", "1:34: undefined: y (undefined)"}},
+		{"let x = x", []string{"1:5: x declared and not used (unused-variable)", "1:9: undefined: x (undefined)"}},
+		{"let a = 1; let a = 2; a", []string{"1:5: a declared and not used (unused-variable)"}},
+		{"let [a, b] = [1, 2]; b", []string{"1:6: a declared and not used (unused-variable)"}},
+		{"export let a = 1; let _b = 2;", []string{}},
+		{"let f = fn(x, y) { x }; f(1, 2)", []string{"1:15: parameter y is not used (unused-parameter)"}},
+		{"let f = fn(_, ...more) { more }; f(1)", []string{}},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", []string{}},
+		{
+			"let x = 1; let f = fn(x) { x }; f(x)",
+			[]string{"1:23: x shadows the variable declared at 1:5 (shadow)"},
+		},
+		{
+			"fn(a) { match (a) { [a] => a } }",
+			[]string{"1:22: a shadows the variable declared at 1:4 (shadow)"},
+		},
+		{"let f = fn() { let y = 1; let y = y + 1; y }; f()", []string{}},
+		{"let f = fn() { return 1; 2; 3 }; f()", []string{"1:26: unreachable code after return (unreachable)"}},
+		{"throw 1; let a = 2; a", []string{"1:10: unreachable code after throw (unreachable)"}},
+		{"if (true) { 1 } else { 2 }", []string{"1:5: condition is always true (constant-condition)"}},
+		{"if (0) { 1 }", []string{"1:5: condition is always true (constant-condition)"}},
+		{"match (1) { n if false => n, _ => 0 }", []string{"1:18: condition is always false (constant-condition)"}},
+		{
+			"let f = fn() { 1 }; let g = f; f == g",
+			[]string{"1:32: functions compared with == are only equal when they are the same function (function-comparison)"},
+		},
+		{
+			"let g = fn() { 1 }; g != fn() { 1 }",
+			[]string{"1:21: functions compared with != are only equal when they are the same function (function-comparison)"},
+		},
+		{"let f = fn(a) { a }; let g = fn(h) { h == f }; g(1)", []string{"1:38: functions compared with == are only equal when they are the same function (function-comparison)"}},
+		{"let f = fn(a, b) { a + b }; f(1)", []string{"1:29: wrong number of arguments to f: want=2, got=1 (arity)"}},
+		{"let f = fn(a, b = 1) { a + b }; f(1, 2, 3)", []string{"1:33: wrong number of arguments to f: want 1 to 2, got=3 (arity)"}},
+		{"let f = fn(a, ...b) { [a, b] }; f()", []string{"1:33: wrong number of arguments to f: want at least 1, got=0 (arity)"}},
+		{"let f = fn(a, b = 1) { a + b }; f(b: 2, a: 1)", []string{}},
+		{"fn(a) { a }(1, 2)", []string{"1:1: wrong number of arguments to function: want=1, got=2 (arity)"}},
+	}
+
+	for _, tt := range tests {
+		diagnostics := []string{}
+		for _, d := range Lint(parse(t, tt.input)) {
+			diagnostics = append(diagnostics, d.String())
+		}
+		assert.Equal(t, tt.expected, diagnostics, tt.input)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	diagnostics := Lint(parse(t, "let f = fn() {\n    return 1;\n    2;\n    3\n}; f()"))
+	if assert.Len(t, diagnostics, 1) {
+		assert.Equal(t, "3:5", diagnostics[0].Pos.String())
+		assert.Equal(t, "4:6", diagnostics[0].End.String())
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+	return program
+}
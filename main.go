@@ -1,11 +1,475 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/compiler"
+	"github.com/computerphilosopher/monkey-interpreter/evaluator"
+	"github.com/computerphilosopher/monkey-interpreter/format"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/lint"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/optimizer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
 	"github.com/computerphilosopher/monkey-interpreter/repl"
+	"github.com/computerphilosopher/monkey-interpreter/resolver"
+	"github.com/computerphilosopher/monkey-interpreter/vm"
 )
 
+const usage = `usage:
+	monkey                            start the REPL
+	monkey run [-path dirs] [-engine eval|vm] [-O=false] [limits] file.mk
+	                                  run a script or compiled file and print its result
+	monkey compile [-o file.mkc] [-O=false] file.mk
+	                                  compile a script to bytecode
+	monkey disasm [-O=false] file     list the bytecode of a script or compiled file
+	monkey fmt [-w] files...          print scripts formatted, or rewrite them with -w
+	monkey parse [-json|-dot] file.mk print the syntax tree of a script, as JSON or
+	                                  as a Graphviz graph
+	monkey lint [-json] files...      report likely mistakes in scripts, as text or
+	                                  as a JSON array
+
+limits, 0 for none:
+	-max-depth n                      nested function calls (default 10000)
+	-max-steps n                      evaluated nodes, or instructions on the VM
+	-max-allocs n                     allocated objects
+	-max-bytes n                      approximate bytes allocated
+	-timeout d                        wall time, such as 500ms or 2s
+`
+
+const optimizeUsage = "optimize the program before running or compiling it"
+
 func main() {
-	repl.Start(os.Stdin, os.Stdout)
+	if len(os.Args) < 2 {
+		repl.Start(os.Stdin, os.Stdout)
+		return
+	}
+
+	switch os.Args[1] {
+	case "run":
+		os.Exit(run(os.Args[2:]))
+	case "compile":
+		os.Exit(compile(os.Args[2:]))
+	case "disasm":
+		os.Exit(disasm(os.Args[2:]))
+	case "fmt":
+		os.Exit(formatFiles(os.Args[2:]))
+	case "parse":
+		os.Exit(parse(os.Args[2:]))
+	case "lint":
+		os.Exit(lintFiles(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	searchPath := flags.String("path", os.Getenv("MONKEYPATH"),
+		"module search path, separated by "+string(os.PathListSeparator))
+	engine := flags.String("engine", "eval",
+		"eval to walk the AST, or vm to compile it to bytecode")
+	optimize := flags.Bool("O", true, optimizeUsage)
+	limits := object.Limits{}
+	flags.IntVar(&limits.MaxDepth, "max-depth", 10000, "maximum depth of nested function calls")
+	flags.IntVar(&limits.MaxSteps, "max-steps", 0,
+		"maximum number of evaluated nodes, or instructions on the VM")
+	flags.IntVar(&limits.MaxAllocations, "max-allocs", 0, "maximum number of allocated objects")
+	flags.IntVar(&limits.MaxBytes, "max-bytes", 0, "maximum number of bytes allocated")
+	timeout := flags.Duration("timeout", 0, "maximum time to run for")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || (*engine != "eval" && *engine != "vm") {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	file := flags.Arg(0)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	dir, path := filepath.Dir(file), filepath.SplitList(*searchPath)
+
+	var result object.Object
+	switch {
+	case compiler.IsBytecode(source):
+		// A compiled file can only run on the VM, whatever the engine.
+		bytecode := &compiler.Bytecode{}
+		if err := bytecode.UnmarshalBinary(source); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return 1
+		}
+		result, err = runBytecode(bytecode, dir, path, limits, *timeout)
+	case *engine == "vm":
+		program, ok := parseFile(file, source, *optimize)
+		if !ok {
+			return 1
+		}
+		result, err = runOnVM(program, dir, path, limits, *timeout)
+	default:
+		program, ok := parseFile(file, source, *optimize)
+		if !ok || !resolveFile(file, program) {
+			return 1
+		}
+		result, err = evalProgram(program, dir, path, limits, *timeout)
+	}
+
+	if errObj, ok := err.(*object.Error); ok {
+		fmt.Fprintf(os.Stderr, "%s:%s: %s\n", file, errObj.Pos, errObj.Message)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return 1
+	}
+	if result != nil && result != object.NullValue {
+		fmt.Println(result.Inspect())
+	}
+
+	return 0
+}
+
+func compile(args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: the script with a .mkc extension)")
+	optimize := flags.Bool("O", true, optimizeUsage)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	file := flags.Arg(0)
+	bytecode, ok := compileFile(file, *optimize)
+	if !ok {
+		return 1
+	}
+
+	data, err := bytecode.MarshalBinary()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return 1
+	}
+
+	out := *output
+	if out == "" {
+		out = strings.TrimSuffix(file, filepath.Ext(file)) + ".mkc"
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func disasm(args []string) int {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	optimize := flags.Bool("O", true, optimizeUsage)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	bytecode, ok := compileFile(flags.Arg(0), *optimize)
+	if !ok {
+		return 1
+	}
+
+	fmt.Print(compiler.Disassemble(bytecode))
+	return 0
+}
+
+func parse(args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	asDot := flags.Bool("dot", false, "print the tree as a Graphviz graph")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || (*asJSON && *asDot) {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	file := flags.Arg(0)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	program, ok := parseFile(file, source, false)
+	if !ok {
+		return 1
+	}
+
+	switch {
+	case *asDot:
+		fmt.Print(ast.Dot(program))
+		return 0
+	case !*asJSON:
+		fmt.Println(program.String())
+		return 0
+	}
+
+	data, err := json.MarshalIndent(program, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+func formatFiles(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		if !formatFile(file, *write) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// formatFile formats a script, writing it back to file if it changed when
+// write is set. Errors are reported on stderr.
+func formatFile(file string, write bool) bool {
+	info, err := os.Stat(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	formatted, err := format.Source(source)
+	if parseErr, ok := err.(*format.ParseError); ok {
+		for _, err := range parseErr.Errors {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		}
+		return false
+	}
+
+	if !write {
+		os.Stdout.Write(formatted)
+		return true
+	}
+	if bytes.Equal(source, formatted) {
+		return true
+	}
+	if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
+}
+
+// fileDiagnostic is a lint diagnostic as printed by monkey lint -json.
+type fileDiagnostic struct {
+	File string `json:"file"`
+	lint.Diagnostic
+}
+
+// lintFiles reports the diagnostics of every file, and fails if there are
+// any or a file does not parse.
+func lintFiles(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the diagnostics as a JSON array")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	status := 0
+	diagnostics := []fileDiagnostic{}
+	for _, file := range flags.Args() {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		program, ok := parseFile(file, source, false)
+		if !ok {
+			status = 1
+			continue
+		}
+		for _, d := range lint.Lint(program) {
+			diagnostics = append(diagnostics, fileDiagnostic{File: file, Diagnostic: d})
+		}
+	}
+	if len(diagnostics) != 0 {
+		status = 1
+	}
+
+	if !*asJSON {
+		for _, d := range diagnostics {
+			fmt.Printf("%s:%s\n", d.File, d.Diagnostic)
+		}
+		return status
+	}
+
+	data, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return status
+}
+
+// compileFile returns the bytecode of a script, or loads it if file is
+// already compiled. Errors are reported on stderr.
+func compileFile(file string, optimize bool) (*compiler.Bytecode, bool) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+
+	bytecode := &compiler.Bytecode{}
+	if compiler.IsBytecode(source) {
+		if err := bytecode.UnmarshalBinary(source); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return nil, false
+		}
+		return bytecode, true
+	}
+
+	program, ok := parseFile(file, source, optimize)
+	if !ok {
+		return nil, false
+	}
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return nil, false
+	}
+
+	return c.Bytecode(), true
+}
+
+func parseFile(file string, source []byte, optimize bool) (*ast.Program, bool) {
+	p := parser.New(lexer.NewLexer(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, err := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		}
+		return nil, false
+	}
+
+	if optimize {
+		program = optimizer.Optimize(program)
+	}
+	return program, true
+}
+
+// resolveFile binds the identifiers of a script for the evaluator,
+// reporting the undefined names and duplicate parameters on stderr.
+func resolveFile(file string, program *ast.Program) bool {
+	errs := resolver.Resolve(program, nil)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", file, err)
+	}
+	return len(errs) == 0
+}
+
+func evalProgram(
+	program *ast.Program,
+	dir string,
+	searchPath []string,
+	limits object.Limits,
+	timeout time.Duration,
+) (object.Object, error) {
+	ctx, cancel := timeoutContext(timeout)
+	defer cancel()
+
+	budget := object.NewBudget(limits)
+	loader := evaluator.NewModuleLoader(dir, searchPath)
+	loader.SetBudget(budget)
+
+	env := object.NewEnvironment()
+	env.SetImporter(loader)
+	env.SetBudget(budget)
+
+	evaluated := evaluator.EvalContext(ctx, program, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		return nil, errObj
+	}
+	return evaluated, nil
+}
+
+func runOnVM(
+	program *ast.Program,
+	dir string,
+	searchPath []string,
+	limits object.Limits,
+	timeout time.Duration,
+) (object.Object, error) {
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return nil, err
+	}
+	return runBytecode(c.Bytecode(), dir, searchPath, limits, timeout)
+}
+
+func runBytecode(
+	bytecode *compiler.Bytecode,
+	dir string,
+	searchPath []string,
+	limits object.Limits,
+	timeout time.Duration,
+) (object.Object, error) {
+	ctx, cancel := timeoutContext(timeout)
+	defer cancel()
+
+	budget := object.NewBudget(limits)
+	machine := vm.New(bytecode)
+	machine.SetImporter(evaluator.NewModuleLoaderWithRunner(dir, searchPath, vm.ModuleRunner(budget)))
+	machine.SetBudget(budget)
+	if err := machine.RunContext(ctx); err != nil {
+		return nil, err
+	}
+	return machine.LastPoppedStackElem(), nil
+}
+
+// timeoutContext returns a context that is done after timeout, or never when
+// timeout is 0.
+func timeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
 }
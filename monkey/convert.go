@@ -0,0 +1,362 @@
+package monkey
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+var objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+
+// ToObject converts a Go value to a monkey value. Integers, booleans and
+// strings become their monkey counterparts, slices and arrays become
+// arrays, and maps and structs become hashes. The fields of a struct are
+// keyed by their name, or by the name given in a `monkey:"name"` tag; a tag
+// of "-" leaves the field out. Nil pointers, slices, maps and interfaces
+// become null, and values that are already monkey objects are kept as is.
+// A value that contains itself is an error.
+func ToObject(v interface{}) (object.Object, error) {
+	return toObject(reflect.ValueOf(v), path{})
+}
+
+// reference identifies the pointer, map or slice a value refers to. The
+// type and length tell apart a struct from its first field and a slice from
+// a shorter one sharing its start.
+type reference struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// path holds the references being converted, from the value passed to
+// ToObject down to the current one. Meeting one of them again means the
+// value contains itself.
+type path map[reference]bool
+
+func toObject(v reflect.Value, p path) (object.Object, error) {
+	if !v.IsValid() {
+		return object.NullValue, nil
+	}
+	if v.Type().Implements(objectType) && v.CanInterface() {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return object.NullValue, nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			ref := reference{v.Type(), v.Pointer(), 0}
+			if v.Kind() == reflect.Slice {
+				ref.len = v.Len()
+			}
+			if p[ref] {
+				return nil, fmt.Errorf("cycle through %s", v.Type())
+			}
+			p[ref] = true
+			defer delete(p, ref)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return object.TrueValue, nil
+		}
+		return object.FalseValue, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows Integer", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return object.NullValue, nil
+		}
+		return toObject(v.Elem(), p)
+	case reflect.Slice:
+		if v.IsNil() {
+			return object.NullValue, nil
+		}
+		return sliceToArray(v, p)
+	case reflect.Array:
+		return sliceToArray(v, p)
+	case reflect.Map:
+		if v.IsNil() {
+			return object.NullValue, nil
+		}
+		return mapToHash(v, p)
+	case reflect.Struct:
+		return structToHash(v, p)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a monkey value", v.Type())
+	}
+}
+
+func sliceToArray(v reflect.Value, p path) (object.Object, error) {
+	elements := make([]object.Object, v.Len())
+	for i := range elements {
+		element, err := toObject(v.Index(i), p)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		elements[i] = element
+	}
+	return &object.Array{Elements: elements}, nil
+}
+
+func mapToHash(v reflect.Value, p path) (object.Object, error) {
+	hash := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := toObject(iter.Key(), p)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+		}
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("key %v: unusable as hash key: %s", iter.Key(), key.Type())
+		}
+
+		value, err := toObject(iter.Value(), p)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+		}
+		hash.Pairs[hashable.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return hash, nil
+}
+
+func structToHash(v reflect.Value, p path) (object.Object, error) {
+	hash := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		value, err := toObject(v.Field(i), p)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		key := &object.String{Value: name}
+		hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return hash, nil
+}
+
+// fieldName returns the hash key of a struct field, and false for the
+// fields that are not converted.
+func fieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	switch tag := field.Tag.Get("monkey"); tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// FromObject stores a monkey value in the Go value target points to,
+// following the rules of ToObject in reverse. An integer that does not fit
+// the target type is an error rather than being truncated. Stored in an
+// empty interface, null becomes nil, integers int64, arrays []interface{},
+// and hashes map[string]interface{} if all their keys are strings, or
+// map[interface{}]interface{} otherwise, down to the values they contain.
+// Functions and other values without a Go counterpart can only be stored in
+// an interface or object.Object.
+func FromObject(obj object.Object, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("target must be a non-nil pointer")
+	}
+	return fromObject(obj, v.Elem())
+}
+
+func fromObject(obj object.Object, v reflect.Value) error {
+	if v.Type() == objectType {
+		v.Set(reflect.ValueOf(&obj).Elem())
+		return nil
+	}
+
+	if obj == object.NullValue {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			break
+		}
+		v.Set(reflect.ValueOf(toGo(obj)))
+		return nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return fromObject(obj, v.Elem())
+	case reflect.Bool:
+		if boolean, ok := obj.(*object.Boolean); ok {
+			v.SetBool(boolean.Value)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if integer, ok := obj.(*object.Integer); ok {
+			if v.OverflowInt(integer.Value) {
+				return fmt.Errorf("%d overflows %s", integer.Value, v.Type())
+			}
+			v.SetInt(integer.Value)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if integer, ok := obj.(*object.Integer); ok {
+			if integer.Value < 0 || v.OverflowUint(uint64(integer.Value)) {
+				return fmt.Errorf("%d overflows %s", integer.Value, v.Type())
+			}
+			v.SetUint(uint64(integer.Value))
+			return nil
+		}
+	case reflect.String:
+		if str, ok := obj.(*object.String); ok {
+			v.SetString(str.Value)
+			return nil
+		}
+	case reflect.Slice:
+		if array, ok := obj.(*object.Array); ok {
+			v.Set(reflect.MakeSlice(v.Type(), len(array.Elements), len(array.Elements)))
+			return arrayToSlice(array, v)
+		}
+	case reflect.Array:
+		if array, ok := obj.(*object.Array); ok {
+			if len(array.Elements) != v.Len() {
+				return fmt.Errorf("cannot convert Array of length %d to %s",
+					len(array.Elements), v.Type())
+			}
+			return arrayToSlice(array, v)
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*object.Hash); ok {
+			return hashToMap(hash, v)
+		}
+	case reflect.Struct:
+		if hash, ok := obj.(*object.Hash); ok {
+			return hashToStruct(hash, v)
+		}
+	}
+
+	return fmt.Errorf("cannot convert %s to %s", obj.Type(), v.Type())
+}
+
+func arrayToSlice(array *object.Array, v reflect.Value) error {
+	for i, element := range array.Elements {
+		if err := fromObject(element, v.Index(i)); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func hashToMap(hash *object.Hash, v reflect.Value) error {
+	m := reflect.MakeMapWithSize(v.Type(), len(hash.Pairs))
+
+	for _, pair := range hash.Pairs {
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := fromObject(pair.Key, key); err != nil {
+			return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		if err := fromObject(pair.Value, value); err != nil {
+			return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+		}
+		m.SetMapIndex(key, value)
+	}
+
+	v.Set(m)
+	return nil
+}
+
+// hashToStruct sets the fields whose key is in hash. The other fields, and
+// the keys that match no field, are left alone.
+func hashToStruct(hash *object.Hash, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		key := &object.String{Value: name}
+		pair, ok := hash.Pairs[key.HashKey()]
+		if !ok {
+			continue
+		}
+		if err := fromObject(pair.Value, v.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// toGo converts obj to the Go value stored in an empty interface.
+func toGo(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = toGo(element)
+		}
+		return elements
+	case *object.Hash:
+		return hashToGo(obj)
+	default:
+		return obj
+	}
+}
+
+func hashToGo(hash *object.Hash) interface{} {
+	stringKeys := map[string]interface{}{}
+	anyKeys := map[interface{}]interface{}{}
+
+	for _, pair := range hash.Pairs {
+		key, value := toGo(pair.Key), toGo(pair.Value)
+		if str, ok := key.(string); ok && stringKeys != nil {
+			stringKeys[str] = value
+		} else {
+			stringKeys = nil
+		}
+		anyKeys[key] = value
+	}
+
+	if stringKeys != nil {
+		return stringKeys
+	}
+	return anyKeys
+}
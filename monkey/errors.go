@@ -0,0 +1,70 @@
+package monkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// ErrLimitExceeded is wrapped by the RuntimeError of a script that exceeded
+// one of its Limits. A script stopped by its context wraps the context's
+// error instead.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// ParseError lists the syntax errors and the undefined names that kept a
+// script from running.
+type ParseError struct {
+	Errors []error
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// RuntimeError is an error raised by a script and not caught by it. Value is
+// the value given to throw, or nil for errors raised by the interpreter.
+type RuntimeError struct {
+	Message string
+	Line    int
+	Column  int
+	Value   object.Object
+
+	err error
+}
+
+func newRuntimeError(ctx context.Context, errObj *object.Error) *RuntimeError {
+	runtimeErr := &RuntimeError{
+		Message: errObj.Message,
+		Line:    errObj.Pos.Line,
+		Column:  errObj.Pos.Column,
+		Value:   errObj.Value,
+	}
+
+	switch errObj.Kind {
+	case object.LimitError:
+		runtimeErr.err = ErrLimitExceeded
+	case object.CanceledError:
+		runtimeErr.err = ctx.Err()
+	}
+
+	return runtimeErr
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", token.Position{Line: e.Line, Column: e.Column}, e.Message)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.err
+}
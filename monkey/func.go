@@ -0,0 +1,106 @@
+package monkey
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterFunc defines a global named name that calls the Go function fn.
+// See NewBuiltin for how fn is called.
+func (interp *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	builtin, err := NewBuiltin(name, fn)
+	if err != nil {
+		return err
+	}
+	interp.env.Set(name, builtin)
+	return nil
+}
+
+// NewBuiltin wraps the Go function fn in a builtin. The arguments of a call
+// are converted to the parameter types of fn with FromObject, with the
+// extra arguments of a variadic fn going to its last parameter. fn may
+// return nothing, a value, an error, or a value and an error. The value is
+// converted with ToObject, and a non-nil error is raised in the script as
+// an error with the same message, as is a panic in fn.
+func NewBuiltin(name string, fn interface{}) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("%s: not a function: %T", name, fn)
+	}
+
+	t := v.Type()
+	switch {
+	case t.NumOut() > 2:
+		return nil, fmt.Errorf("%s: function returns %d values, want at most 2", name, t.NumOut())
+	case t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("%s: second result of function must be error, got %s", name, t.Out(1))
+	}
+
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			in, err := convertArguments(name, t, args)
+			if err != nil {
+				return err
+			}
+			return convertResults(name, v.Call(in))
+		},
+	}, nil
+}
+
+func convertArguments(name string, t reflect.Type, args []object.Object) ([]reflect.Value, *object.Error) {
+	required := t.NumIn()
+	if t.IsVariadic() {
+		required--
+		if len(args) < required {
+			return nil, newError("wrong number of arguments: want at least %d, got=%d", required, len(args))
+		}
+	} else if len(args) != required {
+		return nil, newError("wrong number of arguments: want=%d, got=%d", required, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if i < required {
+			paramType = t.In(i)
+		} else {
+			paramType = t.In(required).Elem()
+		}
+
+		value := reflect.New(paramType).Elem()
+		if err := fromObject(arg, value); err != nil {
+			return nil, newError("argument %d to %s: %s", i+1, name, err)
+		}
+		in[i] = value
+	}
+
+	return in, nil
+}
+
+func convertResults(name string, out []reflect.Value) object.Object {
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return newError("%s", err.Interface().(error))
+		}
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		return object.NullValue
+	}
+
+	result, err := toObject(out[0], path{})
+	if err != nil {
+		return newError("result of %s: %s", name, err)
+	}
+	return result
+}
+
+func newError(format string, args ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,154 @@
+// Package monkey embeds the interpreter in Go programs. An Interpreter
+// keeps its globals from one Run to the next, so a host can define values
+// for a script, run it, and read back what it defined:
+//
+//	interp := monkey.New(monkey.Options{Limits: monkey.Limits{Timeout: time.Second}})
+//	interp.Set("name", &object.String{Value: "world"})
+//	result, err := interp.Run(ctx, `"hello " + name`)
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/computerphilosopher/monkey-interpreter/evaluator"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/resolver"
+)
+
+// Options configures an Interpreter. The zero value runs scripts without
+// limits, prints to the standard streams, and imports modules relative to
+// the working directory.
+type Options struct {
+	// Stdout and Stderr receive what puts and eputs print.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Limits bounds every Run.
+	Limits Limits
+
+	// ModuleDir is the directory imports are resolved against, and
+	// ModulePath the directories searched after it.
+	ModuleDir  string
+	ModulePath []string
+}
+
+// Limits bounds the resources a single Run may use. A zero field means that
+// resource is not limited.
+type Limits struct {
+	MaxDepth       int // nested function calls
+	MaxSteps       int // evaluated nodes
+	MaxAllocations int // allocated objects
+	MaxBytes       int // approximate size of the allocated objects
+	Timeout        time.Duration
+}
+
+// Interpreter runs scripts in a shared global environment. It is not safe
+// for concurrent use.
+type Interpreter struct {
+	options Options
+	env     *object.Environment
+	loader  *evaluator.ModuleLoader
+}
+
+func New(options Options) *Interpreter {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+	if options.ModuleDir == "" {
+		options.ModuleDir = "."
+	}
+
+	interp := &Interpreter{
+		options: options,
+		env:     object.NewEnvironment(),
+		loader:  evaluator.NewModuleLoader(options.ModuleDir, options.ModulePath),
+	}
+	interp.env.SetImporter(interp.loader)
+	interp.env.Set("puts", &object.Builtin{Name: "puts", Fn: object.Printer(options.Stdout)})
+	interp.env.Set("eputs", &object.Builtin{Name: "eputs", Fn: object.Printer(options.Stderr)})
+
+	return interp
+}
+
+// Run evaluates source and returns the value of its last statement. A
+// script that does not parse or uses a name that is not defined returns a
+// *ParseError, and an error that the script does not catch is returned as
+// a *RuntimeError. A panic in a host function is raised in the script as an
+// error.
+func (interp *Interpreter) Run(ctx context.Context, source string) (object.Object, error) {
+	p := parser.New(lexer.NewLexer(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.Errors()}
+	}
+	if errs := resolver.Resolve(program, interp.env.Names()); len(errs) != 0 {
+		return nil, &ParseError{Errors: errs}
+	}
+
+	limits := interp.options.Limits
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	budget := object.NewBudget(object.Limits{
+		MaxDepth:       limits.MaxDepth,
+		MaxSteps:       limits.MaxSteps,
+		MaxAllocations: limits.MaxAllocations,
+		MaxBytes:       limits.MaxBytes,
+	})
+	interp.env.SetBudget(budget)
+	interp.loader.SetBudget(budget)
+
+	result := evaluator.EvalContext(ctx, program, interp.env)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, newRuntimeError(ctx, errObj)
+	}
+	if result == nil {
+		return object.NullValue, nil
+	}
+	return result, nil
+}
+
+// Set defines a global visible to the scripts run afterwards.
+func (interp *Interpreter) Set(name string, value object.Object) {
+	interp.env.Set(name, value)
+}
+
+// Get returns the value of a global defined by a script or by Set.
+func (interp *Interpreter) Get(name string) (object.Object, bool) {
+	return interp.env.Get(name)
+}
+
+// SetValue converts v with ToObject and defines it as a global.
+func (interp *Interpreter) SetValue(name string, v interface{}) error {
+	obj, err := ToObject(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	interp.env.Set(name, obj)
+	return nil
+}
+
+// GetValue stores the value of a global in the Go value target points to,
+// converting it with FromObject.
+func (interp *Interpreter) GetValue(name string, target interface{}) error {
+	obj, ok := interp.env.Get(name)
+	if !ok {
+		return fmt.Errorf("undefined global: %s", name)
+	}
+	if err := FromObject(obj, target); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
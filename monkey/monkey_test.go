@@ -0,0 +1,303 @@
+package monkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	interp := New(Options{Stdout: &stdout, Stderr: &stderr})
+
+	interp.Set("name", &object.String{Value: "world"})
+	result, err := interp.Run(context.Background(), `let greeting = "hello " + name; puts(greeting); eputs(1); greeting`)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", result.Inspect())
+	assert.Equal(t, "hello world\n", stdout.String())
+	assert.Equal(t, "1\n", stderr.String())
+
+	greeting, ok := interp.Get("greeting")
+	if assert.True(t, ok) {
+		assert.Equal(t, "hello world", greeting.Inspect())
+	}
+
+	result, err = interp.Run(context.Background(), "let twice = fn(s) { s + s };")
+	assert.NoError(t, err)
+	assert.Equal(t, object.NullValue, result)
+
+	result, err = interp.Run(context.Background(), "twice(greeting)")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello worldhello world", result.Inspect())
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected string
+		target   error
+	}{
+		{"let = 1;", Options{}, "expected next token to be Ident, got Assign instead", nil},
+		{"let f = fn() {\n  missing\n}", Options{}, "2:3: identifier not found: missing", nil},
+		{"fn(a, a) { a }", Options{}, "1:7: duplicate parameter: a", nil},
+		{"1 +\ntrue", Options{}, "1:3: type mismatch: Integer + Boolean", nil},
+		{`throw "oops"`, Options{}, "1:1: oops", nil},
+		{"let f = fn() { 1 + f() }; f()", Options{Limits: Limits{MaxDepth: 50}},
+			"1:21: maximum call depth exceeded: 50", ErrLimitExceeded},
+		{"let f = fn() { f() }; f()", Options{Limits: Limits{Timeout: 10 * time.Millisecond}},
+			"1:17: context deadline exceeded", context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		_, err := New(tt.options).Run(context.Background(), tt.input)
+		if assert.Error(t, err, tt.input) {
+			assert.Contains(t, err.Error(), tt.expected, tt.input)
+			if tt.target != nil {
+				assert.True(t, errors.Is(err, tt.target), tt.input)
+			}
+		}
+	}
+
+	_, err := New(Options{}).Run(context.Background(), "let = 1;")
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr))
+
+	_, err = New(Options{}).Run(context.Background(), "missing")
+	assert.True(t, errors.As(err, &parseErr))
+
+	_, err = New(Options{}).Run(context.Background(), `throw {"code": 7}`)
+	var runtimeErr *RuntimeError
+	if assert.True(t, errors.As(err, &runtimeErr)) {
+		assert.Equal(t, `{code: 7}`, runtimeErr.Value.Inspect())
+	}
+}
+
+func TestRunRecoversHostPanics(t *testing.T) {
+	interp := New(Options{})
+	interp.Set("fail", &object.Builtin{Name: "fail", Fn: func(args ...object.Object) object.Object {
+		panic("out of order")
+	}})
+
+	_, err := interp.Run(context.Background(), "fail()")
+	var runtimeErr *RuntimeError
+	if assert.True(t, errors.As(err, &runtimeErr)) {
+		assert.Equal(t, "fail panicked: out of order", runtimeErr.Message)
+	}
+
+	result, err := interp.Run(context.Background(), "1 + 1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", result.Inspect())
+}
+
+func TestLimitsApplyToEachRun(t *testing.T) {
+	interp := New(Options{Limits: Limits{MaxSteps: 100}})
+
+	for i := 0; i < 3; i++ {
+		_, err := interp.Run(context.Background(), "let [a, ...b] = [1, 2, 3]; a")
+		assert.NoError(t, err)
+	}
+}
+
+type player struct {
+	Name    string `monkey:"name"`
+	Score   uint8  `monkey:"score"`
+	Tags    []string
+	Secret  string `monkey:"-"`
+	private int
+}
+
+type node struct {
+	Next *node
+}
+
+func TestToObject(t *testing.T) {
+	var nilPointer *player
+
+	selfPointer := &node{}
+	selfPointer.Next = selfPointer
+	selfMap := map[string]interface{}{}
+	selfMap["self"] = selfMap
+	selfSlice := []interface{}{1, nil}
+	selfSlice[1] = selfSlice
+	shared := []int{1}
+
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{42, "42"},
+		{int8(-3), "-3"},
+		{uint32(7), "7"},
+		{true, "true"},
+		{"hi", "hi"},
+		{[]int{1, 2}, "[1, 2]"},
+		{[2]bool{true, false}, "[true, false]"},
+		{map[string]int{"a": 1}, "{a: 1}"},
+		{player{Name: "ann", Score: 3, Secret: "x"}, "{Tags: null, name: ann, score: 3}"},
+		{&player{Tags: []string{"a"}}, "{Tags: [a], name: , score: 0}"},
+		{nilPointer, "null"},
+		{nil, "null"},
+		{&object.Integer{Value: 5}, "5"},
+		{[][]int{shared, shared}, "[[1], [1]]"},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if assert.NoError(t, err, "%v", tt.input) {
+			assert.Equal(t, tt.expected, inspectSorted(obj), "%v", tt.input)
+		}
+	}
+
+	errorTests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{1.5, "cannot convert float64 to a monkey value"},
+		{uint64(1 << 63), "9223372036854775808 overflows Integer"},
+		{[]interface{}{1, make(chan int)}, "index 1: cannot convert chan int to a monkey value"},
+		{struct{ F func() }{}, "field F: cannot convert func() to a monkey value"},
+		{map[[1]int]int{{1}: 1}, "key [1]: unusable as hash key: Array"},
+		{selfPointer, "field Next: cycle through *monkey.node"},
+		{selfMap, "key self: cycle through map[string]interface {}"},
+		{selfSlice, "index 1: cycle through []interface {}"},
+	}
+
+	for _, tt := range errorTests {
+		_, err := ToObject(tt.input)
+		assert.EqualError(t, err, tt.expected, "%v", tt.input)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	interp := New(Options{})
+	_, err := interp.Run(context.Background(), `
+	let p = {"name": "bob", "score": 200, "Tags": ["x", "y"], "extra": 1};
+	let big = 300;
+	let scores = {"a": 1, "b": "x"};
+	let none = if (false) { 1 };
+	let nested = [{"a": 1}, {2: true}, none, {"b": none}];
+	let f = fn(x) { x };`)
+	assert.NoError(t, err)
+
+	var p player
+	if assert.NoError(t, interp.GetValue("p", &p)) {
+		assert.Equal(t, player{Name: "bob", Score: 200, Tags: []string{"x", "y"}}, p)
+	}
+
+	var pp *player
+	assert.NoError(t, interp.GetValue("p", &pp))
+	assert.Equal(t, "bob", pp.Name)
+
+	var nested interface{}
+	if assert.NoError(t, interp.GetValue("nested", &nested)) {
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"a": int64(1)},
+			map[interface{}]interface{}{int64(2): true},
+			nil,
+			map[string]interface{}{"b": nil},
+		}, nested)
+	}
+
+	var fn object.Object
+	assert.NoError(t, interp.GetValue("f", &fn))
+	assert.Equal(t, object.FunctionObject, string(fn.Type()))
+
+	var small int8
+	assert.EqualError(t, interp.GetValue("big", &small), "big: 300 overflows int8")
+	var str string
+	assert.EqualError(t, interp.GetValue("big", &str), "big: cannot convert Integer to string")
+	var scores map[string]uint8
+	assert.EqualError(t, interp.GetValue("scores", &scores), "scores: key b: cannot convert String to uint8")
+	assert.EqualError(t, interp.GetValue("missing", &str), "undefined global: missing")
+	assert.EqualError(t, FromObject(object.NullValue, str), "target must be a non-nil pointer")
+
+	assert.NoError(t, interp.SetValue("q", player{Name: "cy", Score: 9}))
+	result, err := interp.Run(context.Background(), "let {name} = q; name")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "cy", result.Inspect())
+	}
+}
+
+// inspectSorted inspects obj with the pairs of hashes sorted, so that the
+// result does not depend on map iteration order.
+func inspectSorted(obj object.Object) string {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		return obj.Inspect()
+	}
+
+	pairs := []string{}
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair.Key.Inspect()+": "+inspectSorted(pair.Value))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func TestRegisterFunc(t *testing.T) {
+	interp := New(Options{})
+
+	assert.NoError(t, interp.RegisterFunc("add", func(a, b int) int { return a + b }))
+	assert.NoError(t, interp.RegisterFunc("join", func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	}))
+	assert.NoError(t, interp.RegisterFunc("lookup", func(key string) (player, error) {
+		if key == "ann" {
+			return player{Name: "ann", Score: 1}, nil
+		}
+		return player{}, fmt.Errorf("no player %s", key)
+	}))
+	assert.NoError(t, interp.RegisterFunc("boom", func() { panic("bad state") }))
+	assert.NoError(t, interp.RegisterFunc("first", func(values []interface{}) interface{} { return values[0] }))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1, 2)", "3"},
+		{`join("-")`, ""},
+		{`join("-", "a", "b", "c")`, "a-b-c"},
+		{`let {score} = lookup("ann"); score`, "1"},
+		{`try { lookup("bob") } catch ({message}) { message }`, "no player bob"},
+		{`try { boom() } catch ({message}) { message }`, "boom panicked: bad state"},
+		{`first([[1, "a"]])`, "[1, a]"},
+		{"let f = fn(n) { add(n, 1) }; f(1)", "2"},
+	}
+
+	for _, tt := range tests {
+		result, err := interp.Run(context.Background(), tt.input)
+		if assert.NoError(t, err, tt.input) {
+			assert.Equal(t, tt.expected, result.Inspect(), tt.input)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1)", "1:4: wrong number of arguments: want=2, got=1"},
+		{`add(1, "2")`, "1:4: argument 2 to add: cannot convert String to int"},
+		{"join()", "1:5: wrong number of arguments: want at least 1, got=0"},
+		{`join(",", 1)`, "1:5: argument 2 to join: cannot convert Integer to string"},
+		{"boom()", "1:5: boom panicked: bad state"},
+		{"add(a: 1, b: 2)", "1:4: builtin add does not accept named arguments"},
+	}
+
+	for _, tt := range errorTests {
+		_, err := interp.Run(context.Background(), tt.input)
+		assert.EqualError(t, err, tt.expected, tt.input)
+	}
+
+	assert.EqualError(t, interp.RegisterFunc("x", 1), "x: not a function: int")
+	assert.EqualError(t, interp.RegisterFunc("x", func() (int, int) { return 0, 0 }),
+		"x: second result of function must be error, got int")
+}
@@ -0,0 +1,112 @@
+package object
+
+import (
+	"context"
+	"fmt"
+)
+
+// Limits bounds the resources an evaluation may use. A zero field means
+// that resource is not limited.
+type Limits struct {
+	MaxDepth       int // nested function calls
+	MaxSteps       int // evaluated nodes
+	MaxAllocations int // allocated objects
+	MaxBytes       int // approximate size of the allocated objects
+}
+
+// Budget counts the resources used by an evaluation against its Limits.
+// Every method returns a LimitError once a limit is exceeded.
+type Budget struct {
+	limits      Limits
+	ctx         context.Context
+	depth       int
+	steps       int
+	allocations int
+	bytes       int
+}
+
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// Step counts one evaluated node.
+func (b *Budget) Step() *Error {
+	b.steps++
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		return newLimitError("step limit exceeded: %d", b.limits.MaxSteps)
+	}
+	return nil
+}
+
+// SetContext makes the evaluation stop with a CanceledError once ctx is
+// done. A nil ctx is never done.
+func (b *Budget) SetContext(ctx context.Context) {
+	b.ctx = ctx
+}
+
+// Enter counts a function call, which must be matched by Leave when the
+// call returns. Since calls are the only way to loop, this is also where
+// the context is checked.
+func (b *Budget) Enter() *Error {
+	if err := b.Continue(); err != nil {
+		return err
+	}
+
+	b.depth++
+	if b.limits.MaxDepth > 0 && b.depth > b.limits.MaxDepth {
+		b.depth--
+		return newLimitError("maximum call depth exceeded: %d", b.limits.MaxDepth)
+	}
+	return nil
+}
+
+// Continue checks the context for a call that takes the place of its caller
+// instead of nesting in it, as a tail call does on the VM.
+func (b *Budget) Continue() *Error {
+	if b.ctx != nil {
+		if err := b.ctx.Err(); err != nil {
+			return &Error{Kind: CanceledError, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+func (b *Budget) Leave() {
+	b.depth--
+}
+
+// Allocate counts an allocated object of the given size in bytes.
+func (b *Budget) Allocate(bytes int) *Error {
+	b.allocations++
+	b.bytes += bytes
+	if b.limits.MaxAllocations > 0 && b.allocations > b.limits.MaxAllocations {
+		return newLimitError("allocation limit exceeded: %d objects", b.limits.MaxAllocations)
+	}
+	if b.limits.MaxBytes > 0 && b.bytes > b.limits.MaxBytes {
+		return newLimitError("memory limit exceeded: %d bytes", b.limits.MaxBytes)
+	}
+	return nil
+}
+
+// SizeOf approximates the bytes taken by obj, not counting the objects it
+// refers to. The shared booleans and null take none.
+func SizeOf(obj Object) int {
+	switch obj := obj.(type) {
+	case *Integer:
+		return 8
+	case *String:
+		return 16 + len(obj.Value)
+	case *Array:
+		return 24 + 16*len(obj.Elements)
+	case *Hash:
+		return 48 + 64*len(obj.Pairs)
+	case *Function, *Closure:
+		return 64
+	default:
+		return 0
+	}
+}
+
+func newLimitError(format string, args ...interface{}) *Error {
+	return &Error{Kind: LimitError, Message: fmt.Sprintf(format, args...)}
+}
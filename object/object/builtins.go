@@ -0,0 +1,46 @@
+package object
+
+import (
+	"fmt"
+	"io"
+)
+
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BuiltinObject
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
+
+// Call calls the host function of b. A panic in it is returned as an error
+// raised in the script, and a nil result as null.
+func (b *Builtin) Call(args ...Object) (result Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = &Error{Message: fmt.Sprintf("%s panicked: %v", b.Name, r)}
+		}
+	}()
+
+	if result = b.Fn(args...); result == nil {
+		result = NullValue
+	}
+	return result
+}
+
+// Printer returns a builtin function like puts that writes to w.
+func Printer(w io.Writer) BuiltinFunction {
+	return func(args ...Object) Object {
+		for _, arg := range args {
+			fmt.Fprintln(w, arg.Inspect())
+		}
+		return nil
+	}
+}
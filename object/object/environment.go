@@ -0,0 +1,96 @@
+package object
+
+// Importer loads the module imported by an import statement.
+type Importer interface {
+	Import(path string) (*Module, error)
+}
+
+type Environment struct {
+	store    map[string]Object
+	slots    []Object
+	outer    *Environment
+	importer Importer
+	budget   *Budget
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		store: map[string]Object{},
+	}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+func (env *Environment) Get(name string) (Object, bool) {
+	obj, ok := env.store[name]
+	if !ok && env.outer != nil {
+		return env.outer.Get(name)
+	}
+	return obj, ok
+}
+
+func (env *Environment) Set(name string, val Object) Object {
+	env.store[name] = val
+	return val
+}
+
+// Names returns the set of names bound in env itself, leaving out those of
+// the environments enclosing it.
+func (env *Environment) Names() map[string]bool {
+	names := make(map[string]bool, len(env.store))
+	for name := range env.store {
+		names[name] = true
+	}
+	return names
+}
+
+// GetSlot returns the value in slot of the environment depth levels out
+// from env, and whether the slot has been set.
+func (env *Environment) GetSlot(depth, slot int) (Object, bool) {
+	for ; depth > 0; depth-- {
+		env = env.outer
+	}
+	if slot >= len(env.slots) || env.slots[slot] == nil {
+		return nil, false
+	}
+	return env.slots[slot], true
+}
+
+// SetSlot stores val in slot of env. A slot holding nil is unset.
+func (env *Environment) SetSlot(slot int, val Object) Object {
+	for len(env.slots) <= slot {
+		env.slots = append(env.slots, nil)
+	}
+	env.slots[slot] = val
+	return val
+}
+
+// SetImporter sets the importer used by the import statements evaluated in
+// env and in the environments enclosed by it.
+func (env *Environment) SetImporter(importer Importer) {
+	env.importer = importer
+}
+
+func (env *Environment) Importer() Importer {
+	if env.importer == nil && env.outer != nil {
+		return env.outer.Importer()
+	}
+	return env.importer
+}
+
+// SetBudget sets the budget charged for the evaluation done in env and in
+// the environments enclosed by it.
+func (env *Environment) SetBudget(budget *Budget) {
+	env.budget = budget
+}
+
+func (env *Environment) Budget() *Budget {
+	if env.budget == nil && env.outer != nil {
+		return env.outer.Budget()
+	}
+	return env.budget
+}
@@ -2,9 +2,12 @@ package object
 
 import (
 	"fmt"
+	"hash/fnv"
 	"strings"
 
 	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/token"
 )
 
 type ObjectType string
@@ -16,6 +19,15 @@ const (
 	ReturnValueObject = "ReturnValue"
 	ErrorObject       = "ErrorObject"
 	FunctionObject    = "Function"
+	StringObject      = "String"
+	ArrayObject       = "Array"
+	HashObject        = "Hash"
+	ModuleObject      = "Module"
+	BuiltinObject     = "Builtin"
+
+	CompiledFunctionObject = "CompiledFunction"
+	ClosureObject          = "Closure"
+	CellObject             = "Cell"
 )
 
 type Object interface {
@@ -58,6 +70,14 @@ func (null *Null) Inspect() string {
 	return "null"
 }
 
+// The evaluator and the VM share these values, so that booleans and null
+// compare by identity whichever engine produced them.
+var (
+	NullValue  = &Null{}
+	TrueValue  = &Boolean{Value: true}
+	FalseValue = &Boolean{Value: false}
+)
+
 type ReturnValue struct {
 	Value Object
 }
@@ -70,8 +90,26 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// ErrorKind tells the errors a script can handle from the ones that stop
+// it. A LimitError is raised when an evaluation exceeds its Limits, and a
+// CanceledError when its context is done. Try expressions only catch
+// runtime errors.
+type ErrorKind int
+
+const (
+	RuntimeError ErrorKind = iota
+	LimitError
+	CanceledError
+)
+
+// Error unwinds the evaluation until it is caught by a try expression. Pos
+// is where the error was raised, and Value is the value given to throw, if
+// any.
 type Error struct {
+	Kind    ErrorKind
 	Message string
+	Pos     token.Position
+	Value   Object
 }
 
 func (e *Error) Type() ObjectType {
@@ -82,8 +120,49 @@ func (e *Error) Inspect() string {
 	return "ERROR: " + e.Message
 }
 
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewThrownError returns the error raised by throwing value. Its message is
+// the thrown string, or the inspected value otherwise.
+func NewThrownError(value Object) *Error {
+	message := value.Inspect()
+	if str, ok := value.(*String); ok {
+		message = str.Value
+	}
+
+	return &Error{
+		Message: message,
+		Value:   value,
+	}
+}
+
+// ToHash converts a caught error to the value bound by a catch clause: a
+// hash with its message, position and the thrown value, or null for errors
+// raised by the interpreter itself.
+func (e *Error) ToHash() *Hash {
+	value := e.Value
+	if value == nil {
+		value = NullValue
+	}
+
+	hash := &Hash{Pairs: map[HashKey]HashPair{}}
+	set := func(key string, value Object) {
+		keyObj := &String{Value: key}
+		hash.Pairs[keyObj.HashKey()] = HashPair{Key: keyObj, Value: value}
+	}
+
+	set("message", &String{Value: e.Message})
+	set("line", &Integer{Value: int64(e.Pos.Line)})
+	set("column", &Integer{Value: int64(e.Pos.Column)})
+	set("value", value)
+
+	return hash
+}
+
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []ast.Pattern
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -102,9 +181,177 @@ func (f *Function) Inspect() string {
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ","))
-	out.WriteString(") {\n")
+	out.WriteString(") ")
 	out.WriteString(f.Body.String())
-	out.WriteString("\n")
 
 	return out.String()
 }
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType {
+	return StringObject
+}
+
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (array *Array) Type() ObjectType {
+	return ArrayObject
+}
+
+func (array *Array) Inspect() string {
+	out := strings.Builder{}
+	elements := []string{}
+	for _, e := range array.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by the objects that can be used as a hash key.
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (integer *Integer) HashKey() HashKey {
+	return HashKey{Type: integer.Type(), Value: uint64(integer.Value)}
+}
+
+func (boolean *Boolean) HashKey() HashKey {
+	var value uint64
+	if boolean.Value {
+		value = 1
+	}
+	return HashKey{Type: boolean.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+func (hash *Hash) Type() ObjectType {
+	return HashObject
+}
+
+func (hash *Hash) Inspect() string {
+	out := strings.Builder{}
+	pairs := []string{}
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+type Module struct {
+	Path    string
+	Exports map[string]Object
+}
+
+func (module *Module) Type() ObjectType {
+	return ModuleObject
+}
+
+func (module *Module) Inspect() string {
+	return fmt.Sprintf("<module %s>", module.Path)
+}
+
+// CompiledFunction is a function literal compiled to bytecode. Its first
+// NumParameters locals hold the parameters, followed by the rest parameter
+// when the function is variadic. ParameterNames holds the names that can
+// be given as named arguments, with an empty name for destructuring
+// parameters, and LocalNames the names of all the locals, by slot.
+type CompiledFunction struct {
+	Name           string
+	Instructions   code.Instructions
+	SourceMap      code.SourceMap
+	NumLocals      int
+	NumParameters  int
+	NumRequired    int
+	Variadic       bool
+	ParameterNames []string
+	LocalNames     []string
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return CompiledFunctionObject
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure is a compiled function with the values of its free variables.
+// Program is the program the closure was created in, whose constants and
+// globals the function reads even when it is called from another program,
+// as the functions exported by a module are.
+type Closure struct {
+	Fn      *CompiledFunction
+	Free    []Object
+	Program *Program
+}
+
+// Cell holds a local variable that a closure captured. The frame and the
+// closures share it, so that they all see the values it is bound to later.
+// Cells never reach the script.
+type Cell struct {
+	Name  string
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType {
+	return CellObject
+}
+
+func (c *Cell) Inspect() string {
+	return fmt.Sprintf("Cell[%s]", c.Name)
+}
+
+// Program is the constant pool and the globals of a compiled program, which
+// its instructions refer to by index.
+type Program struct {
+	Constants   []Object
+	Globals     []Object
+	GlobalNames []string
+}
+
+func (c *Closure) Type() ObjectType {
+	return ClosureObject
+}
+
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
@@ -0,0 +1,263 @@
+// Package optimizer rewrites a program into an equivalent one that is
+// cheaper to run. It folds operators applied to literals, drops the branch
+// of an if expression that cannot be taken, and removes the statements that
+// follow a return or a throw. An operation that would raise an error, such
+// as a division by zero, is left for the engine to report at run time.
+package optimizer
+
+import (
+	"strconv"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Optimize rewrites program in place and returns it.
+func Optimize(program *ast.Program) *ast.Program {
+	return ast.Modify(program, optimize).(*ast.Program)
+}
+
+// optimize rewrites a node whose children are already optimized.
+func optimize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.Program:
+		node.Statements = optimizeStatements(node.Statements)
+	case *ast.BlockStatement:
+		node.Statements = optimizeStatements(node.Statements)
+	case *ast.PrefixExpression:
+		if folded := foldPrefix(node); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		if folded := foldInfix(node); folded != nil {
+			return folded
+		}
+	case *ast.IfExpression:
+		return pruneIf(node)
+	}
+
+	return node
+}
+
+func optimizeStatements(stmts []ast.Statement) []ast.Statement {
+	result := []ast.Statement{}
+
+	for i, stmt := range stmts {
+		last := i == len(stmts)-1
+
+		if es, ok := stmt.(*ast.ExpressionStatement); ok {
+			if spliced, ok := spliceIf(es, last); ok {
+				result = append(result, spliced...)
+				if endsBlock(spliced) {
+					break
+				}
+				continue
+			}
+		}
+
+		result = append(result, stmt)
+		if endsBlock([]ast.Statement{stmt}) {
+			break
+		}
+	}
+
+	return result
+}
+
+// endsBlock reports whether the last of stmts leaves the block, so that
+// the statements after it are unreachable.
+func endsBlock(stmts []ast.Statement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+
+	switch stmts[len(stmts)-1].(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement:
+		return true
+	default:
+		return false
+	}
+}
+
+// spliceIf replaces an if statement whose condition is known by the
+// statements of the branch that is taken. Blocks share the environment of
+// their enclosing block, so this does not change what their bindings are
+// visible to. A branch that is missing or empty evaluates to no value at
+// all, so the statement can only be dropped when its value is not used.
+func spliceIf(stmt *ast.ExpressionStatement, last bool) ([]ast.Statement, bool) {
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		return nil, false
+	}
+
+	truthy, known := literalTruthiness(exp.Condition)
+	if !known {
+		return nil, false
+	}
+
+	taken := exp.Alternative
+	if truthy {
+		taken = exp.Consequence
+	}
+
+	if taken != nil && len(taken.Statements) != 0 {
+		return taken.Statements, true
+	}
+	if !last {
+		return []ast.Statement{}, true
+	}
+	return nil, false
+}
+
+// pruneIf drops the branch of an if expression whose condition is known.
+// The taken branch replaces the whole expression when it is a single
+// expression, and otherwise stays in an if expression that always takes it.
+func pruneIf(exp *ast.IfExpression) ast.Expression {
+	truthy, known := literalTruthiness(exp.Condition)
+	if !known {
+		return exp
+	}
+
+	taken := exp.Alternative
+	if truthy {
+		taken = exp.Consequence
+	}
+
+	if taken != nil && len(taken.Statements) == 1 {
+		if es, ok := taken.Statements[0].(*ast.ExpressionStatement); ok && es.Expression != nil {
+			return es.Expression
+		}
+	}
+
+	if taken == nil {
+		exp.Consequence = &ast.BlockStatement{
+			Token:      exp.Consequence.Token,
+			RightBrace: exp.Consequence.RightBrace,
+		}
+		exp.Alternative = nil
+		return exp
+	}
+
+	if !truthy {
+		exp.Condition = booleanLiteral(true, exp.Condition)
+	}
+	exp.Consequence = taken
+	exp.Alternative = nil
+	return exp
+}
+
+// literalTruthiness reports whether a literal condition is truthy. Only
+// false and null are falsy, and null has no literal.
+func literalTruthiness(exp ast.Expression) (bool, bool) {
+	switch exp := exp.(type) {
+	case *ast.BooleanLiteral:
+		return exp.Value, true
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+func foldPrefix(exp *ast.PrefixExpression) ast.Expression {
+	switch exp.Operator {
+	case "!":
+		truthy, known := literalTruthiness(exp.Right)
+		if known {
+			return booleanLiteral(!truthy, exp)
+		}
+	case "-":
+		if right, ok := exp.Right.(*ast.IntegerLiteral); ok {
+			return integerLiteral(-right.Value, exp)
+		}
+	}
+
+	return nil
+}
+
+func foldInfix(exp *ast.InfixExpression) ast.Expression {
+	switch left := exp.Left.(type) {
+	case *ast.IntegerLiteral:
+		right, ok := exp.Right.(*ast.IntegerLiteral)
+		if !ok {
+			return nil
+		}
+		return foldIntegers(exp, left.Value, right.Value)
+	case *ast.StringLiteral:
+		right, ok := exp.Right.(*ast.StringLiteral)
+		if !ok {
+			return nil
+		}
+		switch exp.Operator {
+		case "+":
+			return stringLiteral(left.Value+right.Value, exp)
+		case "==":
+			return booleanLiteral(left.Value == right.Value, exp)
+		case "!=":
+			return booleanLiteral(left.Value != right.Value, exp)
+		}
+	case *ast.BooleanLiteral:
+		right, ok := exp.Right.(*ast.BooleanLiteral)
+		if !ok {
+			return nil
+		}
+		switch exp.Operator {
+		case "==":
+			return booleanLiteral(left.Value == right.Value, exp)
+		case "!=":
+			return booleanLiteral(left.Value != right.Value, exp)
+		}
+	}
+
+	return nil
+}
+
+func foldIntegers(exp *ast.InfixExpression, left, right int64) ast.Expression {
+	switch exp.Operator {
+	case "+":
+		return integerLiteral(left+right, exp)
+	case "-":
+		return integerLiteral(left-right, exp)
+	case "*":
+		return integerLiteral(left*right, exp)
+	case "/":
+		if right == 0 {
+			return nil
+		}
+		return integerLiteral(left/right, exp)
+	case "<":
+		return booleanLiteral(left < right, exp)
+	case ">":
+		return booleanLiteral(left > right, exp)
+	case "==":
+		return booleanLiteral(left == right, exp)
+	case "!=":
+		return booleanLiteral(left != right, exp)
+	default:
+		return nil
+	}
+}
+
+// The literals produced by folding keep the position of the expression
+// they replace.
+func integerLiteral(value int64, from ast.Expression) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{
+		Token: token.Token{Type: token.Int, Literal: strconv.FormatInt(value, 10), Pos: from.Pos()},
+		Value: value,
+	}
+}
+
+func stringLiteral(value string, from ast.Expression) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Type: token.String, Literal: value, Pos: from.Pos()},
+		Value: value,
+	}
+}
+
+func booleanLiteral(value bool, from ast.Expression) *ast.BooleanLiteral {
+	tok := token.Token{Type: token.False, Literal: "false", Pos: from.Pos()}
+	if value {
+		tok = token.Token{Type: token.True, Literal: "true", Pos: from.Pos()}
+	}
+	return &ast.BooleanLiteral{Token: tok, Value: value}
+}
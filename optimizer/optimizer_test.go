@@ -0,0 +1,107 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/evaluator"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantFolding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", "50"},
+		{"1 < 2", "true"},
+		{"!(1 == 1)", "false"},
+		{"!5", "false"},
+		{`"a" + "b" == "ab"`, "true"},
+		{"true != false", "true"},
+		{"x + 2 * 3", "(x + 6)"},
+		{"fn(a = 1 + 1) { a * (2 - 1) }", "fn(a = 2) { (a * 1) }"},
+		{"1 / 0", "(1 / 0)"},
+		{"1 + true", "(1 + true)"},
+		{`"a" - "b"`, `("a" - "b")`},
+		{"true + true", "(true + true)"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		assert.Equal(t, tt.expected, program.String(), tt.input)
+	}
+}
+
+func TestDeadBranchElimination(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (1 < 2) { 10 } else { 20 }", "10"},
+		{"if (1 > 2) { 10 } else { 20 }", "20"},
+		{"let x = if (false) { 10 };", "let x = if (false) {};"},
+		{"if (true) { let a = 1; a }; a", "let a = 1; a; a"},
+		{"if (false) { 1 }; 2", "2"},
+		{"let f = fn() { if (true) { let a = 1; a } }", "let f = fn() { let a = 1; a };"},
+		{"let x = if (false) { 1 } else { let a = 1; a }", "let x = if (true) { let a = 1; a };"},
+		{"if (x) { 10 }", "if (x) { 10 }"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		assert.Equal(t, tt.expected, program.String(), tt.input)
+	}
+}
+
+func TestUnreachableStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"return 1; 2; 3", "return 1;"},
+		{"let f = fn() { 1; return 2; 3 }", "let f = fn() { 1; return 2; };"},
+		{"let f = fn() { throw 1; 2 }", "let f = fn() { throw 1; };"},
+		{"if (true) { return 1 }; 2", "return 1;"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		assert.Equal(t, tt.expected, program.String(), tt.input)
+	}
+}
+
+func TestOptimizedProgramsEvaluateTheSame(t *testing.T) {
+	inputs := []string{
+		"(5 + 10 * 2 + 15 / 3) * 2 + -10",
+		"let f = fn() { 5; if (false) { 1 } }; f()",
+		"let f = fn(x) { if (true) { let y = x * 2; return y }; 0 }; f(21)",
+		"match (1 + 1) { 2 => if (true) { 1 } else { 0 }, _ => 3 }",
+		"try { 1 + true } catch ({message}) { message }",
+		"1 + true",
+		"1 / 0",
+		"let a = 1;\nlet b = a + (2 + true);",
+	}
+
+	for _, input := range inputs {
+		expected := evaluator.Eval(parse(t, input), object.NewEnvironment())
+		actual := evaluator.Eval(Optimize(parse(t, input)), object.NewEnvironment())
+
+		assert.Equal(t, expected.Inspect(), actual.Inspect(), input)
+		if expectedErr, ok := expected.(*object.Error); ok {
+			assert.Equal(t, expectedErr.Pos, actual.(*object.Error).Pos, input)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+	return program
+}
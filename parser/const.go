@@ -11,6 +11,7 @@ const (
 	Product     // * or /
 	Prefix      // - or +
 	Call        // myFunction(x)
+	Member      // module.name
 )
 
 func noPrefixParseFnError(t token.TokenType) {
@@ -25,6 +25,7 @@ func precedences() map[token.TokenType]int {
 		token.Slash:       Product,
 		token.Star:        Product,
 		token.LeftParen:   Call,
+		token.Dot:         Member,
 	}
 }
 
@@ -60,6 +61,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LessThan, p.parseInfixExpression)
 	p.registerInfix(token.GreaterThan, p.parseInfixExpression)
 	p.registerInfix(token.LeftParen, p.parseCallExpression)
+	p.registerInfix(token.Dot, p.parseMemberExpression)
 
 	p.registerPrefix(token.LeftParen, p.parseGroupedExpression)
 
@@ -67,6 +69,14 @@ func New(l *lexer.Lexer) *Parser {
 
 	p.registerPrefix(token.Function, p.parseFunctionLiteral)
 
+	p.registerPrefix(token.String, p.parseStringLiteral)
+	p.registerPrefix(token.LeftBracket, p.parseArrayLiteral)
+	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
+
+	p.registerPrefix(token.Match, p.parseMatchExpression)
+
+	p.registerPrefix(token.Try, p.parseTryExpression)
+
 	p.nextToken()
 	p.nextToken()
 	return p
@@ -102,6 +112,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.Return:
 		return p.parseReturnStatement()
+	case token.Import:
+		return p.parseImportStatement()
+	case token.Export:
+		return p.parseExportStatement()
+	case token.Throw:
+		return p.parseThrowStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -111,23 +127,102 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{
 		Token: p.curToken,
 	}
+
+	switch p.peekToken.Type {
+	case token.LeftBracket, token.LeftBrace:
+		p.nextToken()
+		stmt.Pattern = p.parseBindingPattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	default:
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		stmt.Name = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+	}
+
+	if !p.expectPeek(token.Assign) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(Lowest)
+	for p.peekToken.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.String) {
+		return nil
+	}
+
+	stmt.Path = &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	if p.curToken.Literal != "as" {
+		p.errors = append(p.errors,
+			fmt.Errorf("expected as after import path, got %s instead", p.curToken.Literal))
+		return nil
+	}
+
 	if !p.expectPeek(token.Ident) {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{
+	stmt.Alias = &ast.Identifier{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
 
-	if !p.expectPeek(token.Assign) {
+	if p.peekToken.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() ast.Statement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Let) {
+		return nil
+	}
+
+	stmt.Statement = p.parseLetStatement()
+	if stmt.Statement == nil {
 		return nil
 	}
 
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(Lowest)
-	for p.peekToken.Type == token.Semicolon {
+	if stmt.Value == nil {
+		return nil
+	}
+
+	if p.peekToken.Type == token.Semicolon {
 		p.nextToken()
 	}
 
@@ -303,11 +398,16 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	for p.curToken.Type != token.RightBrace &&
 		p.curToken.Type != token.EOF {
 		stmt := p.parseStatement()
+		if _, ok := stmt.(*ast.ExportStatement); ok {
+			p.errors = append(p.errors,
+				fmt.Errorf("export is only allowed at the top level of a module"))
+		}
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	block.RightBrace = p.curToken
 
 	return block
 }
@@ -343,39 +443,85 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.curToken}
 
-	identifiers := []*ast.Identifier{}
-	if p.peekToken.Type == token.RightParen {
-		p.nextToken()
-		return identifiers
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
 	}
+	expression.Block = p.parseBlockStatement()
 
-	p.nextToken()
+	if p.peekToken.Type == token.Catch {
+		p.nextToken()
+		if !p.expectPeek(token.LeftParen) {
+			return nil
+		}
 
-	ident := &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+		p.nextToken()
+		expression.Parameter = p.parseBindingPattern()
+		if expression.Parameter == nil {
+			return nil
+		}
+
+		if !p.expectPeek(token.RightParen) {
+			return nil
+		}
+		if !p.expectPeek(token.LeftBrace) {
+			return nil
+		}
+		expression.Catch = p.parseBlockStatement()
 	}
-	identifiers = append(identifiers, ident)
 
-	for p.peekToken.Type == token.Comma {
-		p.nextToken()
+	if p.peekToken.Type == token.Finally {
 		p.nextToken()
+		if !p.expectPeek(token.LeftBrace) {
+			return nil
+		}
+		expression.Finally = p.parseBlockStatement()
+	}
 
-		ident := &ast.Identifier{
-			Token: p.curToken,
-			Value: p.curToken.Literal,
+	if expression.Catch == nil && expression.Finally == nil {
+		p.peekError(token.Catch)
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseFunctionParameters() []ast.Pattern {
+	params := p.parsePatternList(token.RightParen, p.parseParameter)
+
+	hasDefault := false
+	for _, param := range params {
+		_, isDefault := param.(*ast.DefaultPattern)
+		_, isRest := param.(*ast.RestPattern)
+		if hasDefault && !isDefault && !isRest {
+			p.errors = append(p.errors,
+				fmt.Errorf("parameter %s without default follows parameter with default", param.String()))
+			return nil
 		}
+		hasDefault = hasDefault || isDefault
+	}
+
+	return params
+}
 
-		identifiers = append(identifiers, ident)
+func (p *Parser) parseParameter() ast.Pattern {
+	param := p.parseBindingPattern()
+	if param == nil || p.peekToken.Type != token.Assign {
+		return param
 	}
 
-	if !p.expectPeek(token.RightParen) {
-		return nil
+	p.nextToken()
+	pattern := &ast.DefaultPattern{
+		Token:  p.curToken,
+		Target: param,
 	}
 
-	return identifiers
+	p.nextToken()
+	pattern.Default = p.parseExpression(Lowest)
+
+	return pattern
 }
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
@@ -401,31 +547,132 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		Token:    p.curToken,
 		Function: function,
 	}
-	exp.Arguments = p.parseCallArguments()
+	exp.Arguments, exp.NamedArguments = p.parseCallArguments()
+	exp.RightParen = p.curToken
 	return exp
 }
 
-func (p *Parser) parseCallArguments() []ast.Expression {
+func (p *Parser) parseCallArguments() ([]ast.Expression, []*ast.NamedArgument) {
 	args := []ast.Expression{}
+	named := []*ast.NamedArgument{}
+
+	for p.peekToken.Type != token.RightParen {
+		p.nextToken()
+
+		if p.curToken.Type == token.Ident && p.peekToken.Type == token.Colon {
+			name := &ast.Identifier{
+				Token: p.curToken,
+				Value: p.curToken.Literal,
+			}
+			p.nextToken()
+			p.nextToken()
+			named = append(named, &ast.NamedArgument{
+				Name:  name,
+				Value: p.parseExpression(Lowest),
+			})
+		} else if len(named) > 0 {
+			p.errors = append(p.errors,
+				fmt.Errorf("positional argument %s follows named argument", p.curToken.Literal))
+			return nil, nil
+		} else {
+			args = append(args, p.parseExpression(Lowest))
+		}
+
+		if p.peekToken.Type != token.RightParen && !p.expectPeek(token.Comma) {
+			return nil, nil
+		}
+	}
+
+	if !p.expectPeek(token.RightParen) {
+		return nil, nil
+	}
+
+	return args, named
+}
 
-	if p.peekToken.Type == token.RightParen {
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekToken.Type == end {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
 
-	args = append(args, p.parseExpression(Lowest))
+	list = append(list, p.parseExpression(Lowest))
 
 	for p.peekToken.Type == token.Comma {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(Lowest))
+		list = append(list, p.parseExpression(Lowest))
 	}
 
-	if !p.expectPeek(token.RightParen) {
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token:  p.curToken,
+		Object: object,
+	}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	return exp
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RightBracket)
+	array.RightBracket = p.curToken
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = []ast.HashPair{}
+
+	for p.peekToken.Type != token.RightBrace {
+		p.nextToken()
+		key := p.parseExpression(Lowest)
+
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(Lowest)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if p.peekToken.Type != token.RightBrace && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
 		return nil
 	}
+	hash.RightBrace = p.curToken
 
-	return args
+	return hash
 }
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"strconv"
 	"testing"
 
@@ -11,6 +10,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// parseProgram parses input, which must be valid, and checks that the
+// program prints as source that parses back to the same program.
+func parseProgram(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := New(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+
+	printed := program.String()
+	p = New(lexer.NewLexer(printed))
+	reparsed := p.ParseProgram()
+	assert.Empty(t, p.Errors(), printed)
+	assert.True(t, ast.Equal(program, reparsed), "%s\nprints as\n%s", input, printed)
+
+	return program
+}
+
 func TestLetStatement(t *testing.T) {
 	assert := assert.New(t)
 
@@ -25,10 +42,7 @@ func TestLetStatement(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 		assert.Equal(1, len(program.Statements))
 
 		stmt := program.Statements[0]
@@ -58,11 +72,7 @@ func TestReturnStatement(t *testing.T) {
 	}
 
 	for _, tt := range test {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 		assert.Equal(1, len(program.Statements))
 
 		returnStmt, ok := program.Statements[0].(*ast.ReturnStatement)
@@ -134,11 +144,7 @@ func TestIdentifierExpression(t *testing.T) {
 	assert := assert.New(t)
 	input := "foobar;"
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -150,11 +156,7 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	assert := assert.New(t)
 	input := "5;"
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -175,11 +177,7 @@ func TestBooleanExpression(t *testing.T) {
 	assert := assert.New(t)
 	input := "true;"
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -218,11 +216,7 @@ func TestParsingPrefixExpression(t *testing.T) {
 	}
 
 	for _, tt := range prefixTests {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 		assert.Equal(1, len(program.Statements))
 
 		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -254,10 +248,7 @@ func TestParsingInfixExpressions(t *testing.T) {
 	}
 
 	for _, tt := range infixTests {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 		assert.Equal(1, len(program.Statements))
 
 		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -306,7 +297,19 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"3 + 4; -5 * 5",
-			"(3 + 4)((-5) * 5)",
+			"(3 + 4); ((-5) * 5)",
+		},
+		{
+			"if (x) { 1 }; -1",
+			"if (x) { 1 }; (-1)",
+		},
+		{
+			"if (a < b) { let c = a; c } else {}",
+			"if ((a < b)) { let c = a; c } else {}",
+		},
+		{
+			"match (x) { -1 => y, _ => -z }",
+			"match(x) { -1 => y, _ => (-z) }",
 		},
 		{
 			"5 > 4 == 3 < 4",
@@ -358,11 +361,7 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 
 		actual := program.String()
 		assert.Equal(tt.expected, actual)
@@ -373,12 +372,7 @@ func TestIfExpression(t *testing.T) {
 	assert := assert.New(t)
 	input := `if (x < y) { x }`
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-	program := p.ParseProgram()
-
-	assert.Equal(0, len(p.Errors()))
-	fmt.Printf("%+v\n", p.Errors())
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -401,11 +395,7 @@ func TestIfElseExpression(t *testing.T) {
 	assert := assert.New(t)
 	input := `if (x < y) { x } else { y }`
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-	program := p.ParseProgram()
-
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -446,10 +436,7 @@ func TestFunctionParameterParsing(t *testing.T) {
 	assert := assert.New(t)
 
 	for _, tt := range tests {
-		l := lexer.NewLexer(tt.input)
-		p := New(l)
-		program := p.ParseProgram()
-		assert.Equal(0, len(p.Errors()))
+		program := parseProgram(t, tt.input)
 
 		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
 		assert.True(ok)
@@ -463,11 +450,7 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	assert := assert.New(t)
 	input := "fn(x, y) { x + y; }"
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -478,8 +461,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 
 	assert.Equal(2, len(function.Parameters))
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testLiteralExpression(t, function.Parameters[0].(*ast.IdentifierPattern).Name, "x")
+	testLiteralExpression(t, function.Parameters[1].(*ast.IdentifierPattern).Name, "y")
 
 	assert.Equal(1, len(function.Body.Statements))
 
@@ -493,11 +476,7 @@ func TestCallExpressionParsing(t *testing.T) {
 	assert := assert.New(t)
 	input := "add(1, 2 * 3, 4 + 5);"
 
-	l := lexer.NewLexer(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	assert.Equal(0, len(p.Errors()))
+	program := parseProgram(t, input)
 	assert.Equal(1, len(program.Statements))
 
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
@@ -514,3 +493,216 @@ func TestCallExpressionParsing(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
+
+func TestParsingArrayLiterals(t *testing.T) {
+	assert := assert.New(t)
+	input := "[1, 2 * 2, 3 + 3]"
+
+	program := parseProgram(t, input)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(ok)
+
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	assert.True(ok)
+	assert.Equal(3, len(array.Elements))
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	assert := assert.New(t)
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	program := parseProgram(t, input)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(ok)
+
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	assert.True(ok)
+	assert.Equal(3, len(hash.Pairs))
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+	for i, pair := range hash.Pairs {
+		key, ok := pair.Key.(*ast.StringLiteral)
+		assert.True(ok)
+		assert.Equal(expected[i].key, key.Value)
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	assert := assert.New(t)
+	input := `match (x) { 0 => "zero", [a, _] if a > 1 => a, {"k": v} => v, _ => "other" }`
+
+	program := parseProgram(t, input)
+	assert.Equal(1, len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(ok)
+
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+	assert.True(ok)
+	testIdentifier(t, exp.Subject, "x")
+	assert.Equal(4, len(exp.Arms))
+
+	literal, ok := exp.Arms[0].Pattern.(*ast.LiteralPattern)
+	assert.True(ok)
+	testIntegerLiteral(t, literal.Value, 0)
+
+	array, ok := exp.Arms[1].Pattern.(*ast.ArrayPattern)
+	assert.True(ok)
+	assert.Equal(2, len(array.Elements))
+	_, ok = array.Elements[1].(*ast.WildcardPattern)
+	assert.True(ok)
+	testInfixExpression(t, exp.Arms[1].Guard, "a", ">", 1)
+	testIdentifier(t, exp.Arms[1].Body, "a")
+
+	hash, ok := exp.Arms[2].Pattern.(*ast.HashPattern)
+	assert.True(ok)
+	assert.Equal(1, len(hash.Pairs))
+
+	_, ok = exp.Arms[3].Pattern.(*ast.WildcardPattern)
+	assert.True(ok)
+
+	assert.Equal(`match(x) { 0 => "zero", [a, _] if (a > 1) => a, {"k": v} => v, _ => "other" }`,
+		program.String())
+}
+
+func TestDestructuringLetStatement(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...rest] = arr;", "let [a, b, ...rest] = arr;"},
+		{"let [a, [_, b]] = arr;", "let [a, [_, b]] = arr;"},
+		{"let {name, age} = person;", `let {"name": name, "age": age} = person;`},
+		{`let {"k": [v]} = h;`, `let {"k": [v]} = h;`},
+		{"let f = fn([a, b], {c}, ...rest) { a };", `let f = fn([a, b], {"c": c}, ...rest) { a };`},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		assert.Equal(1, len(program.Statements))
+		assert.Equal(tt.expected, program.String())
+	}
+
+	invalid := []string{
+		"let [1] = arr;",
+		"let [...rest, a] = arr;",
+		"let f = fn(...) { 1 };",
+	}
+
+	for _, input := range invalid {
+		l := lexer.NewLexer(input)
+		p := New(l)
+		p.ParseProgram()
+		assert.NotEqual(0, len(p.Errors()), input)
+	}
+}
+
+func TestDefaultAndNamedParameters(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b = 10, ...rest) { a }", "fn(a, b = 10, ...rest) { a }"},
+		{"fn([a, b] = [1, 2]) { a }", "fn([a, b] = [1, 2]) { a }"},
+		{"f(1, b: 2 + 3)", "f(1, b: (2 + 3))"},
+		{"f(a: 1, b: 2)", "f(a: 1, b: 2)"},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		assert.Equal(tt.expected, program.String())
+	}
+
+	invalid := []string{
+		"fn(a = 1, b) { a }",
+		"f(a: 1, 2)",
+	}
+
+	for _, input := range invalid {
+		l := lexer.NewLexer(input)
+		p := New(l)
+		p.ParseProgram()
+		assert.NotEqual(0, len(p.Errors()), input)
+	}
+}
+
+func TestImportExportStatements(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/math.mk" as math;`, `import "lib/math.mk" as math;`},
+		{"export let add = fn(a, b) { a + b };", "export let add = fn(a, b) { (a + b) };"},
+		{"math.add(1, 2)", "math.add(1, 2)"},
+		{"-a.b", "(-a.b)"},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		assert.Equal(tt.expected, program.String())
+	}
+
+	invalid := []string{
+		`import "lib.mk";`,
+		`import "lib.mk" to lib;`,
+		`import lib as lib;`,
+		"export 1;",
+		"fn() { export let a = 1; }",
+	}
+
+	for _, input := range invalid {
+		l := lexer.NewLexer(input)
+		p := New(l)
+		p.ParseProgram()
+		assert.NotEqual(0, len(p.Errors()), input)
+	}
+}
+
+func TestTryExpressionParsing(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { x } catch (e) { y }", "try { x } catch(e) { y }"},
+		{"try { x } finally { y }", "try { x } finally { y }"},
+		{"try { x } catch ({message}) { y } finally { z }", `try { x } catch({"message": message}) { y } finally { z }`},
+		{"throw 1 + 2;", "throw (1 + 2);"},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		assert.Equal(tt.expected, program.String())
+	}
+
+	invalid := []string{
+		"try { x }",
+		"try { x } catch { y }",
+		"try { x } catch (1) { y }",
+		"throw;",
+	}
+
+	for _, input := range invalid {
+		l := lexer.NewLexer(input)
+		p := New(l)
+		p.ParseProgram()
+		assert.NotEqual(0, len(p.Errors()), input)
+	}
+}
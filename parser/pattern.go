@@ -0,0 +1,254 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+const wildcard = "_"
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LeftParen) {
+		return nil
+	}
+
+	p.nextToken()
+
+	expression.Subject = p.parseExpression(Lowest)
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	expression.Arms = []*ast.MatchArm{}
+	for p.peekToken.Type != token.RightBrace {
+		p.nextToken()
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		if p.peekToken.Type != token.RightBrace && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+	expression.RightBrace = p.curToken
+
+	return expression
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekToken.Type == token.If {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(Lowest)
+	}
+
+	if !p.expectPeek(token.FatArrow) {
+		return nil
+	}
+
+	p.nextToken()
+	arm.Body = p.parseExpression(Lowest)
+
+	return arm
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.Int, token.String, token.True, token.False:
+		pattern := &ast.LiteralPattern{Token: p.curToken}
+		pattern.Value = p.prefixParseFns[p.curToken.Type]()
+		return pattern
+	case token.Minus:
+		if p.peekToken.Type != token.Int {
+			p.peekError(token.Int)
+			return nil
+		}
+		pattern := &ast.LiteralPattern{Token: p.curToken}
+		pattern.Value = p.parsePrefixExpression()
+		return pattern
+	case token.LeftBracket:
+		return p.parseArrayPattern(p.parsePattern)
+	case token.LeftBrace:
+		return p.parseHashPattern(p.parsePattern)
+	default:
+		return p.parseBindingPattern()
+	}
+}
+
+// parseBindingPattern parses the patterns allowed on the left-hand side of a
+// let statement and in parameter lists, which must always match.
+func (p *Parser) parseBindingPattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.Ident:
+		if p.curToken.Literal == wildcard {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.IdentifierPattern{
+			Token: p.curToken,
+			Name: &ast.Identifier{
+				Token: p.curToken,
+				Value: p.curToken.Literal,
+			},
+		}
+	case token.LeftBracket:
+		return p.parseArrayPattern(p.parseBindingPattern)
+	case token.LeftBrace:
+		return p.parseHashPattern(p.parseBindingPattern)
+	default:
+		p.errors = append(p.errors,
+			fmt.Errorf("no pattern for %s found", token.TokenTypeLiteral[p.curToken.Type]))
+		return nil
+	}
+}
+
+// parsePatternList parses comma separated patterns up to end. A rest pattern
+// is accepted only as the last element of the list.
+func (p *Parser) parsePatternList(
+	end token.TokenType,
+	parseElement func() ast.Pattern,
+) []ast.Pattern {
+	list := []ast.Pattern{}
+
+	for p.peekToken.Type != end {
+		p.nextToken()
+
+		if p.curToken.Type == token.Ellipsis {
+			rest := p.parseRestPattern()
+			if rest == nil {
+				return nil
+			}
+			list = append(list, rest)
+			break
+		}
+
+		element := parseElement()
+		if element == nil {
+			return nil
+		}
+		list = append(list, element)
+
+		if p.peekToken.Type != end && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseRestPattern() ast.Pattern {
+	pattern := &ast.RestPattern{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	pattern.Name = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseArrayPattern(parseElement func() ast.Pattern) ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	pattern.Elements = p.parsePatternList(token.RightBracket, parseElement)
+	if pattern.Elements == nil {
+		return nil
+	}
+	pattern.RightBracket = p.curToken
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern(parseElement func() ast.Pattern) ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+	pattern.Pairs = []ast.HashPatternPair{}
+
+	for p.peekToken.Type != token.RightBrace {
+		p.nextToken()
+
+		pair, ok := p.parseHashPatternPair(parseElement)
+		if !ok {
+			return nil
+		}
+		pattern.Pairs = append(pattern.Pairs, pair)
+
+		if p.peekToken.Type != token.RightBrace && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+	pattern.RightBrace = p.curToken
+
+	return pattern
+}
+
+func (p *Parser) parseHashPatternPair(
+	parseElement func() ast.Pattern,
+) (ast.HashPatternPair, bool) {
+	// {name} is a shorthand for {"name": name}.
+	if p.curToken.Type == token.Ident &&
+		(p.peekToken.Type == token.Comma || p.peekToken.Type == token.RightBrace) {
+		return ast.HashPatternPair{
+			Key: &ast.StringLiteral{
+				Token: p.curToken,
+				Value: p.curToken.Literal,
+			},
+			Value: &ast.IdentifierPattern{
+				Token: p.curToken,
+				Name: &ast.Identifier{
+					Token: p.curToken,
+					Value: p.curToken.Literal,
+				},
+			},
+		}, true
+	}
+
+	key := p.parseExpression(Lowest)
+	if key == nil {
+		return ast.HashPatternPair{}, false
+	}
+
+	if !p.expectPeek(token.Colon) {
+		return ast.HashPatternPair{}, false
+	}
+
+	p.nextToken()
+	value := parseElement()
+	if value == nil {
+		return ast.HashPatternPair{}, false
+	}
+
+	return ast.HashPatternPair{Key: key, Value: value}, true
+}
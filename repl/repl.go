@@ -9,6 +9,7 @@ import (
 	"github.com/computerphilosopher/monkey-interpreter/lexer"
 	"github.com/computerphilosopher/monkey-interpreter/object/object"
 	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/computerphilosopher/monkey-interpreter/resolver"
 	"github.com/computerphilosopher/monkey-interpreter/token"
 
 	log "github.com/sirupsen/logrus"
@@ -38,6 +39,7 @@ func lex(line string, out io.Writer) {
 func Start(reader io.Reader, writer io.Writer) {
 	scanner := bufio.NewScanner(reader)
 	env := object.NewEnvironment()
+	env.SetImporter(evaluator.NewModuleLoader(".", nil))
 
 	for {
 		fmt.Fprintf(writer, Prompt)
@@ -57,6 +59,11 @@ func Start(reader io.Reader, writer io.Writer) {
 			continue
 		}
 
+		if errs := resolver.Resolve(program, env.Names()); len(errs) != 0 {
+			printParserErrors(writer, errs)
+			continue
+		}
+
 		evaluated := evaluator.Eval(program, env)
 		if evaluated != nil {
 			io.WriteString(writer, evaluated.Inspect())
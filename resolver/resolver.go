@@ -0,0 +1,250 @@
+// Package resolver binds the identifiers of a program to the variables they
+// name before the program is evaluated. The variables of a function call, a
+// match arm or a catch clause live in numbered slots of its environment,
+// and an identifier naming one of them is given the number of environments
+// to go out and the slot to read, so that the evaluator does not look the
+// name up. Globals are still looked up by name, since the host or an
+// earlier line of the REPL can define them; the caller passes their names
+// in so that a use of a name that is defined nowhere is reported.
+//
+// A name is visible from the point where it is bound, as it is when the
+// program runs, except in the body of a function: the function can only be
+// called once the code around it has run, so it sees every name bound in
+// the scopes enclosing it.
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// Error is a use of an undefined name or a parameter declared twice.
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Resolve sets the Binding of the identifiers of program. The globals in
+// known, such as those the host or an earlier line of the REPL defined, are
+// taken to be defined; known may be nil. It returns the errors found, in
+// source order.
+func Resolve(program *ast.Program, known map[string]bool) []error {
+	r := &resolver{known: known, globals: map[string]bool{}}
+
+	r.statements(program.Statements, nil)
+	for len(r.pending) > 0 {
+		function := r.pending[0]
+		r.pending = r.pending[1:]
+		function()
+	}
+
+	sort.SliceStable(r.errors, func(i, j int) bool {
+		a, b := r.errors[i].Pos, r.errors[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	errors := make([]error, len(r.errors))
+	for i, err := range r.errors {
+		errors[i] = err
+	}
+	return errors
+}
+
+// scope holds the slots of the local variables of one environment. The
+// global scope is nil.
+type scope struct {
+	slots map[string]int
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{slots: map[string]int{}, outer: outer}
+}
+
+type resolver struct {
+	known map[string]bool
+	// globals holds the globals the program has bound so far.
+	globals map[string]bool
+	// pending holds the function bodies still to resolve. A body is
+	// resolved after the code around it, so that it sees every name bound
+	// in the scopes enclosing it.
+	pending []func()
+	errors  []*Error
+}
+
+func (r *resolver) fail(ident *ast.Identifier, format string, args ...interface{}) {
+	r.errors = append(r.errors, &Error{Pos: ident.Token.Pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// declare binds ident in s, reusing the slot of a name bound in s before as
+// let does.
+func (r *resolver) declare(ident *ast.Identifier, s *scope) {
+	if s == nil {
+		r.globals[ident.Value] = true
+		ident.Binding = nil
+		return
+	}
+
+	slot, ok := s.slots[ident.Value]
+	if !ok {
+		slot = len(s.slots)
+		s.slots[ident.Value] = slot
+	}
+	ident.Binding = &ast.Binding{Depth: 0, Slot: slot}
+}
+
+func (r *resolver) use(ident *ast.Identifier, s *scope) {
+	for depth := 0; s != nil; depth, s = depth+1, s.outer {
+		if slot, ok := s.slots[ident.Value]; ok {
+			ident.Binding = &ast.Binding{Depth: depth, Slot: slot}
+			return
+		}
+	}
+
+	ident.Binding = nil
+	if !r.globals[ident.Value] && !r.known[ident.Value] {
+		r.fail(ident, "identifier not found: %s", ident.Value)
+	}
+}
+
+func (r *resolver) statements(stmts []ast.Statement, s *scope) {
+	for _, stmt := range stmts {
+		r.statement(stmt, s)
+	}
+}
+
+func (r *resolver) statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		r.expression(stmt.Value, s)
+		if stmt.Pattern != nil {
+			r.pattern(stmt.Pattern, s)
+		} else {
+			r.declare(stmt.Name, s)
+		}
+	case *ast.ReturnStatement:
+		if stmt.ReturnValue != nil {
+			r.expression(stmt.ReturnValue, s)
+		}
+	case *ast.ExpressionStatement:
+		if stmt.Expression != nil {
+			r.expression(stmt.Expression, s)
+		}
+	case *ast.BlockStatement:
+		r.statements(stmt.Statements, s)
+	case *ast.ImportStatement:
+		r.declare(stmt.Alias, s)
+	case *ast.ExportStatement:
+		r.statement(stmt.Statement, s)
+	case *ast.ThrowStatement:
+		r.expression(stmt.Value, s)
+	}
+}
+
+func (r *resolver) expression(exp ast.Expression, s *scope) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		r.use(exp, s)
+	case *ast.PrefixExpression:
+		r.expression(exp.Right, s)
+	case *ast.InfixExpression:
+		r.expression(exp.Left, s)
+		r.expression(exp.Right, s)
+	case *ast.IfExpression:
+		r.expression(exp.Condition, s)
+		r.statements(exp.Consequence.Statements, s)
+		if exp.Alternative != nil {
+			r.statements(exp.Alternative.Statements, s)
+		}
+	case *ast.FunctionLiteral:
+		r.pending = append(r.pending, func() { r.function(exp, s) })
+	case *ast.CallExpression:
+		r.expression(exp.Function, s)
+		for _, arg := range exp.Arguments {
+			r.expression(arg, s)
+		}
+		for _, arg := range exp.NamedArguments {
+			r.expression(arg.Value, s)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range exp.Elements {
+			r.expression(element, s)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range exp.Pairs {
+			r.expression(pair.Key, s)
+			r.expression(pair.Value, s)
+		}
+	case *ast.MatchExpression:
+		r.expression(exp.Subject, s)
+		for _, arm := range exp.Arms {
+			armScope := newScope(s)
+			r.pattern(arm.Pattern, armScope)
+			if arm.Guard != nil {
+				r.expression(arm.Guard, armScope)
+			}
+			r.expression(arm.Body, armScope)
+		}
+	case *ast.MemberExpression:
+		r.expression(exp.Object, s)
+	case *ast.TryExpression:
+		r.statements(exp.Block.Statements, s)
+		if exp.Catch != nil {
+			catchScope := newScope(s)
+			r.pattern(exp.Parameter, catchScope)
+			r.statements(exp.Catch.Statements, catchScope)
+		}
+		if exp.Finally != nil {
+			r.statements(exp.Finally.Statements, s)
+		}
+	}
+}
+
+// function resolves the parameters and the body of a function whose
+// environment encloses s. A default value sees the parameters before it.
+func (r *resolver) function(function *ast.FunctionLiteral, s *scope) {
+	fnScope := newScope(s)
+
+	declared := map[string]bool{}
+	for _, param := range function.Parameters {
+		for _, name := range ast.PatternNames(param) {
+			if declared[name.Value] {
+				r.fail(name, "duplicate parameter: %s", name.Value)
+			}
+			declared[name.Value] = true
+		}
+		r.pattern(param, fnScope)
+	}
+
+	r.statements(function.Body.Statements, fnScope)
+}
+
+// pattern resolves the values in pattern and binds its names in s.
+func (r *resolver) pattern(pattern ast.Pattern, s *scope) {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		r.declare(pattern.Name, s)
+	case *ast.RestPattern:
+		r.declare(pattern.Name, s)
+	case *ast.LiteralPattern:
+		r.expression(pattern.Value, s)
+	case *ast.DefaultPattern:
+		r.expression(pattern.Default, s)
+		r.pattern(pattern.Target, s)
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			r.pattern(element, s)
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			r.expression(pair.Key, s)
+			r.pattern(pair.Value, s)
+		}
+	}
+}
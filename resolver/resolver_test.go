@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"let a = 1; let f = fn(x, y) { let z = x + y; z + a }; f(a, 2)",
+			[]string{"a", "f", "x@0.0", "y@0.1", "z@0.2", "x@0.0", "y@0.1", "z@0.2", "a", "f", "a"},
+		},
+		{
+			"fn(a) { match (a) { [b] => fn() { a + b } } }",
+			[]string{"a@0.0", "a@0.0", "b@0.0", "a@2.0", "b@1.0"},
+		},
+		{
+			"fn() { let f = fn() { g() }; let g = fn() { 1 }; f() }",
+			[]string{"f@0.0", "g@1.1", "g@0.1", "f@0.0"},
+		},
+		{
+			"let x = 1; fn() { let y = x; let x = 2; x }",
+			[]string{"x", "y@0.0", "x", "x@0.1", "x@0.1"},
+		},
+		{
+			"fn(a, b = a, ...c) { b }",
+			[]string{"a@0.0", "b@0.1", "a@0.0", "c@0.2", "b@0.1"},
+		},
+		{
+			"fn(e) { try { e } catch (err) { [e, err] } }",
+			[]string{"e@0.0", "e@0.0", "err@0.0", "e@1.0", "err@0.0"},
+		},
+		{
+			`fn() { import "lib.mk" as lib; lib.x }`,
+			[]string{"lib@0.0", "lib@0.0", "x"},
+		},
+		{"let f = fn() { g() }; let g = fn() { 1 }", []string{"f", "g", "g"}},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		assert.Empty(t, Resolve(program, nil), tt.input)
+		assert.Equal(t, tt.expected, bindings(program), tt.input)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let f = fn() { y }; x", []string{"1:16: identifier not found: y", "1:21: identifier not found: x"}},
+		{"fn(a, [b, a]) { a }", []string{"1:11: duplicate parameter: a"}},
+		{"fn(a, ...a) { a }", []string{"1:10: duplicate parameter: a"}},
+		{"x; let x = 1", []string{"1:1: identifier not found: x"}},
+		{"let x = x", []string{"1:9: identifier not found: x"}},
+		{"match (1) { a => a }; a", []string{"1:23: identifier not found: a"}},
+		{"host + 1", []string{"1:1: identifier not found: host"}},
+	}
+
+	for _, tt := range tests {
+		errors := []string{}
+		for _, err := range Resolve(parse(t, tt.input), nil) {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(t, tt.expected, errors, tt.input)
+	}
+}
+
+func TestKnownNames(t *testing.T) {
+	program := parse(t, "let f = fn() { host }; host")
+	assert.Empty(t, Resolve(program, map[string]bool{"host": true}))
+	assert.Equal(t, []string{"f", "host", "host"}, bindings(program))
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+	return program
+}
+
+// bindings lists the identifiers of program in preorder, each local one
+// followed by the depth and slot it is bound to.
+func bindings(program *ast.Program) []string {
+	names := []string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Identifier)
+		if !ok {
+			return true
+		}
+		if ident.Binding == nil {
+			names = append(names, ident.Value)
+		} else {
+			names = append(names, fmt.Sprintf("%s@%d.%d", ident.Value, ident.Binding.Depth, ident.Binding.Slot))
+		}
+		return true
+	})
+	return names
+}
@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 type TokenType int
 
 const (
@@ -30,11 +32,37 @@ const (
 	Return
 	If
 	Else
+	String
+	LeftBracket
+	RightBracket
+	Colon
+	Match
+	FatArrow
+	Ellipsis
+	Dot
+	Import
+	Export
+	Try
+	Catch
+	Finally
+	Throw
+	Comment
 )
 
+// Position is the 1-based line and column where a token starts.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
 }
 
 var SingleToken map[rune]TokenType = map[rune]TokenType{
@@ -52,18 +80,29 @@ var SingleToken map[rune]TokenType = map[rune]TokenType{
 	'}':    RightBrace,
 	',':    Comma,
 	';':    Semicolon,
+	'[':    LeftBracket,
+	']':    RightBracket,
+	':':    Colon,
+	'.':    Dot,
 	'\x00': EOF,
 }
 
 func GetIdentType(ident string) TokenType {
 	keywords := map[string]TokenType{
-		"let":    Let,
-		"fn":     Function,
-		"return": Return,
-		"if":     If,
-		"else":   Else,
-		"true":   True,
-		"false":  False,
+		"let":     Let,
+		"fn":      Function,
+		"return":  Return,
+		"if":      If,
+		"else":    Else,
+		"true":    True,
+		"false":   False,
+		"match":   Match,
+		"import":  Import,
+		"export":  Export,
+		"try":     Try,
+		"catch":   Catch,
+		"finally": Finally,
+		"throw":   Throw,
 	}
 
 	tokenType, isKeyword := keywords[ident]
@@ -76,31 +115,46 @@ func GetIdentType(ident string) TokenType {
 }
 
 var TokenTypeLiteral = map[TokenType]string{
-	Illegal:     "Illegal",
-	EOF:         "EOF",
-	Ident:       "Ident",
-	Int:         "Int",
-	True:        "True",
-	False:       "False",
-	Bang:        "Bang",
-	Assign:      "Assign",
-	Equal:       "Equal",
-	NotEqual:    "NotEqual",
-	Plus:        "Plus",
-	Minus:       "Minus",
-	Star:        "Star",
-	Slash:       "Slash",
-	LessThan:    "LessThan",
-	GreaterThan: "GreaterThan",
-	Comma:       "Comma",
-	Semicolon:   "Semicolon",
-	LeftParen:   "LeftParen",
-	RightParen:  "RightParen",
-	LeftBrace:   "LeftBrace",
-	RightBrace:  "RightBrace",
-	Function:    "Function",
-	Let:         "Let",
-	Return:      "Return",
-	If:          "If",
-	Else:        "Else",
+	Illegal:      "Illegal",
+	EOF:          "EOF",
+	Ident:        "Ident",
+	Int:          "Int",
+	True:         "True",
+	False:        "False",
+	Bang:         "Bang",
+	Assign:       "Assign",
+	Equal:        "Equal",
+	NotEqual:     "NotEqual",
+	Plus:         "Plus",
+	Minus:        "Minus",
+	Star:         "Star",
+	Slash:        "Slash",
+	LessThan:     "LessThan",
+	GreaterThan:  "GreaterThan",
+	Comma:        "Comma",
+	Semicolon:    "Semicolon",
+	LeftParen:    "LeftParen",
+	RightParen:   "RightParen",
+	LeftBrace:    "LeftBrace",
+	RightBrace:   "RightBrace",
+	Function:     "Function",
+	Let:          "Let",
+	Return:       "Return",
+	If:           "If",
+	Else:         "Else",
+	String:       "String",
+	LeftBracket:  "LeftBracket",
+	RightBracket: "RightBracket",
+	Colon:        "Colon",
+	Match:        "Match",
+	FatArrow:     "FatArrow",
+	Ellipsis:     "Ellipsis",
+	Dot:          "Dot",
+	Import:       "Import",
+	Export:       "Export",
+	Try:          "Try",
+	Catch:        "Catch",
+	Finally:      "Finally",
+	Throw:        "Throw",
+	Comment:      "Comment",
 }
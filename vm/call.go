@@ -0,0 +1,162 @@
+package vm
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+// executeCall calls the function below numArgs positional arguments and
+// numNamed named arguments, each pushed as its name followed by its value.
+// A call in tail position reuses the frame of the caller.
+func (vm *VM) executeCall(numArgs, numNamed int, tail bool) error {
+	base := vm.sp - numArgs - 2*numNamed
+	callee := vm.stack[base-1]
+
+	names := make([]string, numNamed)
+	named := make(map[string]object.Object, numNamed)
+	for i := range names {
+		str, ok := vm.stack[base+numArgs+2*i].(*object.String)
+		if !ok {
+			return malformed(code.OpCall)
+		}
+		name := str.Value
+		if _, ok := named[name]; ok {
+			return newError("duplicate named argument: %s", name)
+		}
+		names[i] = name
+		named[name] = vm.stack[base+numArgs+2*i+1]
+	}
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, base, numArgs, names, named, tail)
+	case *object.Builtin:
+		return vm.callBuiltin(callee, base, numArgs, numNamed)
+	default:
+		return newError("not a function: %s", callee.Type())
+	}
+}
+
+// callClosure binds the arguments starting at base to the parameter slots
+// of cl and enters it. The slots of parameters that were not given are left
+// unset for the prologue of the function to fill with their defaults. A tail
+// call moves cl and its parameters down to the frame of the caller and
+// replaces it.
+func (vm *VM) callClosure(
+	cl *object.Closure,
+	base, numArgs int,
+	names []string,
+	named map[string]object.Object,
+	tail bool,
+) error {
+	fn := cl.Fn
+
+	given := numArgs + len(names)
+	if given < fn.NumRequired || (!fn.Variadic && numArgs > fn.NumParameters) {
+		return arityError(fn.NumRequired, fn.NumParameters, fn.Variadic, given)
+	}
+
+	params := make([]object.Object, fn.NumParameters)
+	for i := range params {
+		name := fn.ParameterNames[i]
+		namedValue, hasNamed := named[name]
+		delete(named, name)
+
+		switch {
+		case i < numArgs:
+			if hasNamed {
+				return newError("multiple values for parameter %s", name)
+			}
+			params[i] = vm.stack[base+i]
+		case hasNamed:
+			params[i] = namedValue
+		case i >= fn.NumRequired:
+		case name != "":
+			return newError("missing argument for parameter %s", name)
+		default:
+			return arityError(fn.NumRequired, fn.NumParameters, fn.Variadic, given)
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := named[name]; ok {
+			return newError("unknown parameter: %s", name)
+		}
+	}
+
+	var rest object.Object
+	if fn.Variadic {
+		from := fn.NumParameters
+		if numArgs < from {
+			from = numArgs
+		}
+		rest = restArray(vm.stack[base:base+numArgs], from)
+	}
+
+	if !tail && vm.framesIndex >= MaxFrames {
+		return newError("stack overflow")
+	}
+	if vm.budget != nil {
+		var err *object.Error
+		if tail {
+			err = vm.budget.Continue()
+		} else {
+			err = vm.budget.Enter()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if tail {
+		base = vm.currentFrame().basePointer
+		vm.stack[base-1] = cl
+	}
+	vm.grow(base + fn.NumLocals + 1)
+
+	copy(vm.stack[base:], params)
+	for i := len(params); i < fn.NumLocals; i++ {
+		vm.stack[base+i] = nil
+	}
+	if rest != nil {
+		vm.stack[base+len(params)] = rest
+	}
+
+	if tail {
+		vm.frames[vm.framesIndex-1] = NewFrame(cl, base)
+	} else {
+		vm.pushFrame(NewFrame(cl, base))
+	}
+	vm.sp = base + fn.NumLocals
+
+	return nil
+}
+
+func (vm *VM) callBuiltin(builtin *object.Builtin, base, numArgs, numNamed int) error {
+	if numNamed != 0 {
+		return newError("builtin %s does not accept named arguments", builtin.Name)
+	}
+
+	args := vm.stack[base : base+numArgs]
+	result := builtin.Call(args...)
+	vm.sp = base - 1
+
+	if err, ok := result.(*object.Error); ok {
+		return err
+	}
+	return vm.push(result)
+}
+
+func arityError(required, total int, variadic bool, got int) *object.Error {
+	switch {
+	case variadic:
+		return newError("wrong number of arguments: want at least %d, got=%d",
+			required, got)
+	case required == total:
+		return newError("wrong number of arguments: want=%d, got=%d",
+			required, got)
+	default:
+		return newError("wrong number of arguments: want %d to %d, got=%d",
+			required, total, got)
+	}
+}
@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+)
+
+var operators = map[code.Opcode]string{
+	code.OpAdd:         "+",
+	code.OpSub:         "-",
+	code.OpMul:         "*",
+	code.OpDiv:         "/",
+	code.OpEqual:       "==",
+	code.OpNotEqual:    "!=",
+	code.OpGreaterThan: ">",
+	code.OpLessThan:    "<",
+}
+
+// binaryOperation applies an infix operator the way the evaluator does, so
+// that both engines agree on results and error messages.
+func binaryOperation(operator string, left, right object.Object) (object.Object, error) {
+	switch {
+	case left.Type() == object.IntegerObject && right.Type() == object.IntegerObject:
+		return integerOperation(operator, left, right)
+	case left.Type() == object.StringObject && right.Type() == object.StringObject:
+		return stringOperation(operator, left, right)
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right), nil
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right), nil
+	case left.Type() != right.Type():
+		return nil, newError("type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
+	default:
+		return nil, newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func integerOperation(operator string, left, right object.Object) (object.Object, error) {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftVal + rightVal}, nil
+	case "-":
+		return &object.Integer{Value: leftVal - rightVal}, nil
+	case "*":
+		return &object.Integer{Value: leftVal * rightVal}, nil
+	case "/":
+		if rightVal == 0 {
+			return nil, newError("division by zero")
+		}
+		return &object.Integer{Value: leftVal / rightVal}, nil
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal), nil
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal), nil
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal), nil
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal), nil
+	default:
+		return nil, newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func stringOperation(operator string, left, right object.Object) (object.Object, error) {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}, nil
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal), nil
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal), nil
+	default:
+		return nil, newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
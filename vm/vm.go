@@ -0,0 +1,804 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/computerphilosopher/monkey-interpreter/ast"
+	"github.com/computerphilosopher/monkey-interpreter/code"
+	"github.com/computerphilosopher/monkey-interpreter/compiler"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/token"
+)
+
+// StackSize is the initial size of the stack, which grows as needed.
+const StackSize = 2048
+const GlobalsSize = 65536
+
+// MaxFrames bounds the depth of nested calls. Calls in tail position reuse
+// the frame of their caller and do not count.
+const MaxFrames = 1 << 16
+
+var (
+	True  = object.TrueValue
+	False = object.FalseValue
+	Null  = object.NullValue
+)
+
+// handler is installed by OpTry. When an error is raised, the VM unwinds to
+// the frame and stack pointer it recorded and jumps to target.
+type handler struct {
+	framesIndex int
+	sp          int
+	target      int
+}
+
+type VM struct {
+	program *object.Program
+
+	stack []object.Object
+	sp    int // Always points to the next free slot. Top of stack is stack[sp-1]
+
+	frames      []*Frame
+	framesIndex int
+
+	handlers []handler
+	importer object.Importer
+	budget   *object.Budget
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		SourceMap:    bytecode.SourceMap,
+	}
+	program := &object.Program{
+		Constants:   bytecode.Constants,
+		Globals:     make([]object.Object, GlobalsSize),
+		GlobalNames: bytecode.Globals,
+	}
+	mainClosure := &object.Closure{Fn: mainFn, Program: program}
+	mainFrame := NewFrame(mainClosure, 0)
+
+	frames := []*Frame{mainFrame}
+
+	return &VM{
+		program: program,
+
+		stack: make([]object.Object, StackSize),
+		sp:    0,
+
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// NewWithGlobalsStore returns a VM that keeps the globals of an earlier run,
+// as the REPL does between lines.
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object) *VM {
+	vm := New(bytecode)
+	vm.program.Globals = s
+	return vm
+}
+
+func (vm *VM) SetImporter(importer object.Importer) {
+	vm.importer = importer
+}
+
+// SetBudget sets the budget the run is charged to. Every instruction counts
+// as a step, and the objects created by arithmetic, literals and closures
+// count as allocations.
+func (vm *VM) SetBudget(budget *object.Budget) {
+	vm.budget = budget
+}
+
+func (vm *VM) LastPoppedStackElem() object.Object {
+	return vm.stack[vm.sp]
+}
+
+// Run executes the bytecode. An error that is not caught by a try
+// expression is returned as an *object.Error.
+func (vm *VM) Run() error {
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		frame := vm.currentFrame()
+		frame.ip++
+		start := frame.ip
+
+		err := vm.execute(frame)
+		if err == nil && vm.budget != nil {
+			err = vm.charge(code.Opcode(frame.Instructions()[start]))
+		}
+		if err != nil {
+			if err := vm.raise(err, frame, start); err != nil {
+				vm.unwind(1)
+				vm.handlers = nil
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunContext runs the bytecode like Run, but stops with a CanceledError once
+// ctx is done. The context is checked at every function call.
+func (vm *VM) RunContext(ctx context.Context) error {
+	if vm.budget == nil {
+		vm.budget = object.NewBudget(object.Limits{})
+		defer func() { vm.budget = nil }()
+	}
+
+	vm.budget.SetContext(ctx)
+	defer vm.budget.SetContext(nil)
+
+	return vm.Run()
+}
+
+// charge counts the instruction op, just executed, against the budget.
+func (vm *VM) charge(op code.Opcode) error {
+	if err := vm.budget.Step(); err != nil {
+		return err
+	}
+
+	switch op {
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMinus,
+		code.OpArray, code.OpHash, code.OpClosure, code.OpRestOf:
+		if size := object.SizeOf(vm.stack[vm.sp-1]); size > 0 {
+			if err := vm.budget.Allocate(size); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unwind leaves the frames above framesIndex, as an error raised in them
+// does.
+func (vm *VM) unwind(framesIndex int) {
+	for vm.framesIndex > framesIndex {
+		vm.popFrame()
+		if vm.budget != nil {
+			vm.budget.Leave()
+		}
+	}
+}
+
+// raise passes err to the innermost handler, or returns it when there is
+// none. The error is stamped with the position of the instruction at
+// offset start of frame, unless it already has one.
+func (vm *VM) raise(err error, frame *Frame, start int) error {
+	rtErr, ok := err.(*object.Error)
+	if !ok {
+		return err
+	}
+
+	if rtErr.Pos == (token.Position{}) {
+		rtErr.Pos = frame.cl.Fn.SourceMap.Lookup(start)
+	}
+
+	// A limit or a canceled context stops the program whatever handlers are
+	// installed.
+	if len(vm.handlers) == 0 || rtErr.Kind != object.RuntimeError {
+		return rtErr
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.unwind(h.framesIndex)
+	vm.sp = h.sp
+	vm.currentFrame().ip = h.target - 1
+
+	return vm.push(rtErr)
+}
+
+func (vm *VM) execute(frame *Frame) error {
+	ins := frame.Instructions()
+	ip := frame.ip
+	op := code.Opcode(ins[ip])
+
+	switch op {
+	case code.OpConstant:
+		constIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		return vm.push(frame.cl.Program.Constants[constIndex])
+
+	case code.OpPop:
+		vm.pop()
+	case code.OpTrue:
+		return vm.push(True)
+	case code.OpFalse:
+		return vm.push(False)
+	case code.OpNull:
+		return vm.push(Null)
+
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+		code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan:
+		right := vm.pop()
+		left := vm.pop()
+		result, err := binaryOperation(operators[op], left, right)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
+
+	case code.OpBang:
+		return vm.push(nativeBoolToBooleanObject(!isTruthy(vm.pop())))
+
+	case code.OpMinus:
+		operand := vm.pop()
+		integer, ok := operand.(*object.Integer)
+		if !ok {
+			return newError("unknown operator: -%s", operand.Type())
+		}
+		return vm.push(&object.Integer{Value: -integer.Value})
+
+	case code.OpJump:
+		pos := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip = pos - 1
+
+	case code.OpJumpNotTruthy:
+		pos := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		if !isTruthy(vm.pop()) {
+			frame.ip = pos - 1
+		}
+
+	case code.OpJumpIfDefined:
+		slot := int(code.ReadUint8(ins[ip+1:]))
+		pos := int(code.ReadUint16(ins[ip+2:]))
+		frame.ip += 3
+		if vm.local(frame, slot) != nil {
+			frame.ip = pos - 1
+		}
+
+	case code.OpSetGlobal:
+		globalIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		frame.cl.Program.Globals[globalIndex] = vm.pop()
+
+	case code.OpGetGlobal:
+		globalIndex := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		value := frame.cl.Program.Globals[globalIndex]
+		if value == nil {
+			return newError("identifier not found: %s", frame.cl.Program.GlobalNames[globalIndex])
+		}
+		return vm.push(value)
+
+	case code.OpSetLocal:
+		localIndex := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		if cell, ok := vm.stack[frame.basePointer+localIndex].(*object.Cell); ok {
+			cell.Value = vm.pop()
+		} else {
+			vm.stack[frame.basePointer+localIndex] = vm.pop()
+		}
+
+	case code.OpGetLocal:
+		localIndex := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		value := vm.local(frame, localIndex)
+		if value == nil {
+			return newError("identifier not found: %s", frame.cl.Fn.LocalNames[localIndex])
+		}
+		return vm.push(value)
+
+	case code.OpGetFree:
+		freeIndex := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		if freeIndex >= len(frame.cl.Free) {
+			return malformed(op)
+		}
+		value := frame.cl.Free[freeIndex]
+		if cell, ok := value.(*object.Cell); ok {
+			if cell.Value == nil {
+				return newError("identifier not found: %s", cell.Name)
+			}
+			value = cell.Value
+		}
+		return vm.push(value)
+
+	case code.OpCurrentClosure:
+		return vm.push(frame.cl)
+
+	case code.OpCaptureLocal:
+		localIndex := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		slot := &vm.stack[frame.basePointer+localIndex]
+		if _, ok := (*slot).(*object.Cell); !ok {
+			*slot = &object.Cell{Name: frame.cl.Fn.LocalNames[localIndex], Value: *slot}
+		}
+		return vm.push(*slot)
+
+	case code.OpCaptureFree:
+		freeIndex := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		if freeIndex >= len(frame.cl.Free) {
+			return malformed(op)
+		}
+		return vm.push(frame.cl.Free[freeIndex])
+
+	case code.OpArray:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+
+		elements := make([]object.Object, numElements)
+		copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+		vm.sp -= numElements
+
+		return vm.push(&object.Array{Elements: elements})
+
+	case code.OpHash:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+
+		hash, err := vm.buildHash(vm.sp-numElements, vm.sp)
+		if err != nil {
+			return err
+		}
+		vm.sp -= numElements
+
+		return vm.push(hash)
+
+	case code.OpIndex:
+		key := vm.pop()
+		hash, ok := vm.pop().(*object.Hash)
+		if !ok {
+			return newError("index operator not supported: %s", key.Type())
+		}
+		return vm.push(hashGet(hash, key))
+
+	case code.OpMember:
+		nameIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		name := frame.cl.Program.Constants[nameIndex].(*object.String).Value
+
+		value, err := member(vm.pop(), name)
+		if err != nil {
+			return err
+		}
+		return vm.push(value)
+
+	case code.OpCall:
+		numArgs := int(code.ReadUint8(ins[ip+1:]))
+		numNamed := int(code.ReadUint8(ins[ip+2:]))
+		frame.ip += 2
+		return vm.executeCall(numArgs, numNamed, vm.inTailPosition(frame))
+
+	case code.OpReturnValue:
+		return vm.returnFrame(vm.pop())
+
+	case code.OpReturn:
+		return vm.returnFrame(Null)
+
+	case code.OpClosure:
+		constIndex := int(code.ReadUint16(ins[ip+1:]))
+		numFree := int(code.ReadUint8(ins[ip+3:]))
+		frame.ip += 3
+		return vm.pushClosure(frame, constIndex, numFree)
+
+	case code.OpDestructureArray:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		hasRest := code.ReadUint8(ins[ip+3:]) == 1
+		frame.ip += 3
+		return vm.destructureArray(numElements, hasRest)
+
+	case code.OpDestructureHash:
+		numKeys := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		return vm.destructureHash(numKeys)
+
+	case code.OpMatchArray:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		hasRest := code.ReadUint8(ins[ip+3:]) == 1
+		frame.ip += 3
+
+		array, ok := vm.pop().(*object.Array)
+		matched := ok && len(array.Elements) >= numElements &&
+			(hasRest || len(array.Elements) == numElements)
+		return vm.push(nativeBoolToBooleanObject(matched))
+
+	case code.OpMatchHash:
+		_, ok := vm.pop().(*object.Hash)
+		return vm.push(nativeBoolToBooleanObject(ok))
+
+	case code.OpHasKey:
+		key := vm.pop()
+		hash, ok := vm.pop().(*object.Hash)
+		if !ok {
+			return malformed(op)
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+		_, found := hash.Pairs[hashKey.HashKey()]
+		return vm.push(nativeBoolToBooleanObject(found))
+
+	case code.OpElement:
+		index := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		array, ok := vm.pop().(*object.Array)
+		if !ok || index >= len(array.Elements) {
+			return malformed(op)
+		}
+		return vm.push(array.Elements[index])
+
+	case code.OpRestOf:
+		from := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		array, ok := vm.pop().(*object.Array)
+		if !ok || from > len(array.Elements) {
+			return malformed(op)
+		}
+		return vm.push(restArray(array.Elements, from))
+
+	case code.OpTry:
+		target := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		vm.handlers = append(vm.handlers, handler{
+			framesIndex: vm.framesIndex,
+			sp:          vm.sp,
+			target:      target,
+		})
+
+	case code.OpEndTry:
+		n := len(vm.handlers)
+		if n == 0 || vm.handlers[n-1].framesIndex != vm.framesIndex {
+			return malformed(op)
+		}
+		vm.handlers = vm.handlers[:n-1]
+
+	case code.OpCatch:
+		err, ok := vm.pop().(*object.Error)
+		if !ok {
+			return malformed(op)
+		}
+		return vm.push(err.ToHash())
+
+	case code.OpThrow:
+		return object.NewThrownError(vm.pop())
+
+	case code.OpRethrow:
+		err, ok := vm.pop().(*object.Error)
+		if !ok {
+			return malformed(op)
+		}
+		return err
+
+	case code.OpImport:
+		pathIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		path := frame.cl.Program.Constants[pathIndex].(*object.String).Value
+
+		if vm.importer == nil {
+			return newError("cannot import %s: no module loader", path)
+		}
+		module, err := vm.importer.Import(path)
+		if err != nil {
+			return newError("%s", err)
+		}
+		return vm.push(module)
+
+	default:
+		return fmt.Errorf("unknown opcode %d", op)
+	}
+
+	return nil
+}
+
+// local returns the value of a local slot of frame, or nil if it is unset.
+func (vm *VM) local(frame *Frame, slot int) object.Object {
+	value := vm.stack[frame.basePointer+slot]
+	if cell, ok := value.(*object.Cell); ok {
+		return cell.Value
+	}
+	return value
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+func (vm *VM) push(o object.Object) error {
+	vm.grow(vm.sp + 1)
+	vm.stack[vm.sp] = o
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+// inTailPosition reports whether the call just read from frame is in tail
+// position: the instructions after it return its result, possibly after
+// jumping out of an if or match expression, and no try expression of the
+// frame is waiting for it. The frame of the main program is never replaced.
+func (vm *VM) inTailPosition(frame *Frame) bool {
+	if vm.framesIndex == 1 {
+		return false
+	}
+	if n := len(vm.handlers); n > 0 && vm.handlers[n-1].framesIndex == vm.framesIndex {
+		return false
+	}
+
+	ins := frame.Instructions()
+	ip := frame.ip + 1
+	for jumps := 0; ip < len(ins) && jumps < len(ins); jumps++ {
+		switch code.Opcode(ins[ip]) {
+		case code.OpReturnValue:
+			return true
+		case code.OpJump:
+			ip = int(code.ReadUint16(ins[ip+1:]))
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// grow makes the stack hold at least size values.
+func (vm *VM) grow(size int) {
+	if size <= len(vm.stack) {
+		return
+	}
+
+	n := 2 * len(vm.stack)
+	if n < size {
+		n = size
+	}
+	stack := make([]object.Object, n)
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+// returnFrame leaves the current frame with rv as its result. A return at
+// the top level ends the program with rv as its value.
+func (vm *VM) returnFrame(rv object.Object) error {
+	if vm.framesIndex == 1 {
+		frame := vm.currentFrame()
+		frame.ip = len(frame.Instructions()) - 1
+		vm.grow(vm.sp + 1)
+		vm.stack[vm.sp] = rv
+		return nil
+	}
+
+	frame := vm.popFrame()
+	if vm.budget != nil {
+		vm.budget.Leave()
+	}
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].framesIndex > vm.framesIndex {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+
+	vm.sp = frame.basePointer - 1
+	return vm.push(rv)
+}
+
+// pushClosure creates a closure over the function at constIndex in the
+// program of frame.
+func (vm *VM) pushClosure(frame *Frame, constIndex int, numFree int) error {
+	constant := frame.cl.Program.Constants[constIndex]
+	function, ok := constant.(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", constant)
+	}
+
+	free := make([]object.Object, numFree)
+	copy(free, vm.stack[vm.sp-numFree:vm.sp])
+	vm.sp -= numFree
+
+	return vm.push(&object.Closure{Fn: function, Free: free, Program: frame.cl.Program})
+}
+
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	pairs := map[object.HashKey]object.HashPair{}
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, newError("unusable as hash key: %s", key.Type())
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}, nil
+}
+
+// destructureArray pushes the elements of the array on top of the stack so
+// that the first one ends up on top, after the rest of the array if hasRest.
+func (vm *VM) destructureArray(numElements int, hasRest bool) error {
+	value := vm.pop()
+	array, ok := value.(*object.Array)
+	if !ok {
+		return newError("cannot destructure %s as %s", value.Type(), object.ArrayObject)
+	}
+
+	if !hasRest && len(array.Elements) != numElements {
+		return newError("array pattern expects %d elements, got %d",
+			numElements, len(array.Elements))
+	}
+	if hasRest && len(array.Elements) < numElements {
+		return newError("array pattern expects at least %d elements, got %d",
+			numElements, len(array.Elements))
+	}
+
+	if hasRest {
+		if err := vm.push(restArray(array.Elements, numElements)); err != nil {
+			return err
+		}
+	}
+	for i := numElements - 1; i >= 0; i-- {
+		if err := vm.push(array.Elements[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// destructureHash looks up the numKeys keys on top of the stack in the hash
+// below them, and pushes the values so that the first one ends up on top.
+func (vm *VM) destructureHash(numKeys int) error {
+	keys := make([]object.Object, numKeys)
+	copy(keys, vm.stack[vm.sp-numKeys:vm.sp])
+	vm.sp -= numKeys
+
+	value := vm.pop()
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return newError("cannot destructure %s as %s", value.Type(), object.HashObject)
+	}
+
+	values := make([]object.Object, numKeys)
+	for i, key := range keys {
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		pair, ok := hash.Pairs[hashKey.HashKey()]
+		if !ok {
+			return newError("key not found in hash: %s", key.Inspect())
+		}
+		values[i] = pair.Value
+	}
+
+	for i := numKeys - 1; i >= 0; i-- {
+		if err := vm.push(values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hashGet(hash *object.Hash, key object.Object) object.Object {
+	hashKey, ok := key.(object.Hashable)
+	if !ok {
+		return Null
+	}
+
+	pair, ok := hash.Pairs[hashKey.HashKey()]
+	if !ok {
+		return Null
+	}
+	return pair.Value
+}
+
+func member(obj object.Object, name string) (object.Object, error) {
+	switch obj := obj.(type) {
+	case *object.Module:
+		value, ok := obj.Exports[name]
+		if !ok {
+			return nil, newError("module %s has no export %s", obj.Path, name)
+		}
+		return value, nil
+	default:
+		return nil, newError("cannot access member %s of %s", name, obj.Type())
+	}
+}
+
+func restArray(values []object.Object, from int) *object.Array {
+	elements := make([]object.Object, len(values)-from)
+	copy(elements, values[from:])
+	return &object.Array{Elements: elements}
+}
+
+func isTruthy(obj object.Object) bool {
+	return obj != Null && obj != False
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return True
+	}
+	return False
+}
+
+// malformed reports an instruction that found the stack, its handlers or
+// its closure in a state the compiler never leaves them in, which only
+// bytecode that was tampered with does. It stops the program rather than
+// being raised in it.
+func malformed(op code.Opcode) error {
+	def, _ := code.Lookup(byte(op))
+	return fmt.Errorf("malformed bytecode: unexpected %s", def.Name)
+}
+
+func newError(format string, args ...interface{}) *object.Error {
+	return &object.Error{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// RunModule compiles and runs the program of a module on a new VM. It is the
+// module runner used when imports are executed by the VM.
+func RunModule(
+	program *ast.Program,
+	importer object.Importer,
+) (map[string]object.Object, error) {
+	return runModule(program, importer, nil)
+}
+
+// ModuleRunner returns a module runner like RunModule that charges budget
+// for running the modules, so that importing a module does not escape the
+// limits of the importing script.
+func ModuleRunner(
+	budget *object.Budget,
+) func(*ast.Program, object.Importer) (map[string]object.Object, error) {
+	return func(program *ast.Program, importer object.Importer) (map[string]object.Object, error) {
+		return runModule(program, importer, budget)
+	}
+}
+
+func runModule(
+	program *ast.Program,
+	importer object.Importer,
+	budget *object.Budget,
+) (map[string]object.Object, error) {
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return nil, err
+	}
+
+	bytecode := c.Bytecode()
+	machine := New(bytecode)
+	machine.SetImporter(importer)
+	machine.SetBudget(budget)
+	if err := machine.Run(); err != nil {
+		return nil, err
+	}
+
+	exports := map[string]object.Object{}
+	for name, index := range bytecode.Exports {
+		if value := machine.program.Globals[index]; value != nil {
+			exports[name] = value
+		}
+	}
+
+	return exports, nil
+}
@@ -0,0 +1,212 @@
+package vm
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/computerphilosopher/monkey-interpreter/compiler"
+	"github.com/computerphilosopher/monkey-interpreter/lexer"
+	"github.com/computerphilosopher/monkey-interpreter/object/object"
+	"github.com/computerphilosopher/monkey-interpreter/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+type vmTestCase struct {
+	input    string
+	expected interface{}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{`let adder = fn(a) { fn(b) { a + b } }; adder(1)(2)`, 3},
+		{`
+		let fib = fn(n) { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) };
+		fib(15)`, 610},
+		{`
+		let outer = fn() {
+			let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } };
+			countdown(3)
+		};
+		outer()`, 0},
+		{`let f = fn() { let a = 1; fn() { let b = 2; fn() { a + b } } }; f()()()`, 3},
+		{`let later = fn() { value }; let value = 7; later()`, 7},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCallingConventions(t *testing.T) {
+	tests := []vmTestCase{
+		{`let f = fn(a, b = a * 2) { a + b }; f(1)`, 3},
+		{`let f = fn(a, b = a * 2) { a + b }; f(b: 1, a: 5)`, 6},
+		{`let f = fn(a, ...rest) { let [b, c] = rest; a + b + c }; f(1, 2, 3)`, 6},
+		{`let f = fn([a, b], {c}) { a + b + c }; f([1, 2], {"c": 3})`, 6},
+		{`let f = fn(a) { a }; f()`, "wrong number of arguments: want=1, got=0"},
+		{`let f = fn(a) { a }; f(1, a: 2)`, "multiple values for parameter a"},
+		{`let f = fn(a, b = 1) { a }; f(1, c: 2)`, "unknown parameter: c"},
+		{`1(2)`, "not a function: Integer"},
+		{`let f = fn() { if (false) { let x = 1 }; x }; f()`, "identifier not found: x"},
+		{`let f = fn() { 1 + f() }; f()`, "stack overflow"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestExceptionUnwinding(t *testing.T) {
+	tests := []vmTestCase{
+		{`
+		let inner = fn() { throw "deep" };
+		let middle = fn() { 1 + inner() };
+		try { middle() } catch ({message}) { message }`, "deep"},
+		{`
+		let f = fn() { try { throw 1 } catch ({value}) { return value + 1 } finally { 10 } };
+		[f(), f()]`, []int64{2, 2}},
+		{`let f = fn(x) { try { x() } catch ({value}) { value } }; f(fn() { throw 5 })`, 5},
+		{`1 + try { throw 2 } catch ({value}) { value }`, 3},
+		{`match ([1, [2, 3]]) { [a, [b, ...c]] if a < b => match (c) { [d] => a + b + d }, _ => 0 }`, 6},
+		{`match ({"a": {"b": 2}}) { {"a": {"c": x}} => x, {"a": {b}} => b }`, 2},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStackGrowth(t *testing.T) {
+	ones := make([]int64, 3000)
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	tests := []vmTestCase{
+		{"[" + strings.Repeat("1, ", 2999) + "1]", ones},
+		{"let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(5000)", 5000},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(100000)", 0},
+		{"let f = fn(n) { match (n) { 0 => 0, _ => f(n - 1) } }; f(100000)", 0},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestErrorPositions(t *testing.T) {
+	input := "let f = fn(x) {\n  x + true\n};\nf(1)"
+
+	program := parser.New(lexer.NewLexer(input)).ParseProgram()
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+
+	err := New(c.Bytecode()).Run()
+	errObj, ok := err.(*object.Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "type mismatch: Integer + Boolean", errObj.Message)
+		assert.Equal(t, 2, errObj.Pos.Line)
+		assert.Equal(t, 5, errObj.Pos.Column)
+	}
+}
+
+func TestGlobalsStore(t *testing.T) {
+	constants := []object.Object{}
+	globals := make([]object.Object, GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
+
+	var machine *VM
+	for _, input := range []string{"let a = 40;", "let b = a + 2;", "b"} {
+		program := parser.New(lexer.NewLexer(input)).ParseProgram()
+
+		c := compiler.NewWithState(symbolTable, constants)
+		assert.NoError(t, c.Compile(program))
+
+		bytecode := c.Bytecode()
+		constants = bytecode.Constants
+
+		machine = NewWithGlobalsStore(bytecode, globals)
+		assert.NoError(t, machine.Run())
+	}
+
+	testExpectedObject(t, "b", 42, machine.LastPoppedStackElem())
+}
+
+// TestMutatedBytecode changes every byte of a compiled program in turn,
+// fixing up the checksum, and checks that the VM runs whatever still loads
+// without panicking.
+func TestMutatedBytecode(t *testing.T) {
+	input := `
+	let f = fn(a, [b, c], d = 1, ...rest) {
+		let g = fn(x) { a + x };
+		try { g(b) + c + d } catch ({message}) { message } finally { rest }
+	};
+	let h = fn({k}) { match ([k, {"v": k}]) { [1, {v}] if v > 0 => v, [_, ...r] => r, _ => 0 } };
+	[f(1, [2, 3], d: 4), h({"k": 1}), -1 / 1 == 1, !true, {1: "one"}]
+	`
+
+	program := parser.New(lexer.NewLexer(input)).ParseProgram()
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+	data, err := c.Bytecode().MarshalBinary()
+	assert.NoError(t, err)
+
+	for i := len(compiler.Magic); i < len(data)-4; i++ {
+		for _, mask := range []byte{0x01, 0x02, 0x80, 0xff} {
+			mutated := append([]byte{}, data[:len(data)-4]...)
+			mutated[i] ^= mask
+			mutated = binary.BigEndian.AppendUint32(mutated, crc32.ChecksumIEEE(mutated))
+
+			bytecode := &compiler.Bytecode{}
+			if bytecode.UnmarshalBinary(mutated) != nil {
+				continue
+			}
+
+			machine := New(bytecode)
+			machine.SetBudget(object.NewBudget(object.Limits{MaxSteps: 10000, MaxDepth: 100}))
+			assert.NotPanics(t, func() { machine.Run() }, "byte %d ^ %#x", i, mask)
+		}
+	}
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		program := parser.New(lexer.NewLexer(tt.input)).ParseProgram()
+
+		c := compiler.New()
+		assert.NoError(t, c.Compile(program), tt.input)
+
+		machine := New(c.Bytecode())
+		if err := machine.Run(); err != nil {
+			testExpectedObject(t, tt.input, tt.expected, err.(*object.Error))
+			continue
+		}
+
+		testExpectedObject(t, tt.input, tt.expected, machine.LastPoppedStackElem())
+	}
+}
+
+func testExpectedObject(t *testing.T, input string, expected interface{}, actual object.Object) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		integer, ok := actual.(*object.Integer)
+		if assert.True(t, ok, "%s: got %s", input, actual.Inspect()) {
+			assert.Equal(t, int64(expected), integer.Value, input)
+		}
+	case string:
+		switch actual := actual.(type) {
+		case *object.String:
+			assert.Equal(t, expected, actual.Value, input)
+		case *object.Error:
+			assert.Equal(t, expected, actual.Message, input)
+		default:
+			t.Errorf("%s: expected %q, got %s", input, expected, actual.Inspect())
+		}
+	case []int64:
+		array, ok := actual.(*object.Array)
+		if assert.True(t, ok, "%s: got %s", input, actual.Inspect()) {
+			assert.Len(t, array.Elements, len(expected), input)
+			for i, value := range expected {
+				testExpectedObject(t, input, int(value), array.Elements[i])
+			}
+		}
+	}
+}